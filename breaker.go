@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+const (
+	breakerFailureThreshold = 5                // consecutive failures before opening
+	breakerOpenTimeout      = 15 * time.Minute // wait before the half-open probe
+	breakerMaxBackoff       = time.Hour
+)
+
+// SourceBreaker is the failure tracking state of one source
+type SourceBreaker struct {
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastFailure         time.Time `json:"last_failure"`
+	LastSuccess         time.Time `json:"last_success"`
+	RetryAt             time.Time `json:"retry_at"`
+	probing             bool
+}
+
+var (
+	breakers      = make(map[string]*SourceBreaker)
+	breakersMutex sync.Mutex
+)
+
+func getBreaker(key string) *SourceBreaker {
+	b, ok := breakers[key]
+	if !ok {
+		b = &SourceBreaker{State: breakerClosed}
+		breakers[key] = b
+	}
+	return b
+}
+
+// breakerAllow reports whether a source may be fetched now. When it may
+// not, it returns the earliest time to try again. An open breaker lets a
+// single probe through once its timeout has passed.
+func breakerAllow(key string, now time.Time) (bool, time.Time) {
+	breakersMutex.Lock()
+	defer breakersMutex.Unlock()
+
+	b := getBreaker(key)
+	switch b.State {
+	case breakerOpen:
+		if now.Before(b.RetryAt) {
+			return false, b.RetryAt
+		}
+		b.State = breakerHalfOpen
+		b.probing = true
+		log.Printf("🔌 Circuit half-open for %s, sending probe", key)
+		return true, time.Time{}
+	case breakerHalfOpen:
+		if b.probing {
+			return false, now.Add(schedulerTick)
+		}
+		b.probing = true
+		return true, time.Time{}
+	default:
+		if now.Before(b.RetryAt) {
+			return false, b.RetryAt
+		}
+		return true, time.Time{}
+	}
+}
+
+// recordFetchSuccess closes the breaker and clears the backoff
+func recordFetchSuccess(key string) {
+	breakersMutex.Lock()
+	defer breakersMutex.Unlock()
+
+	b := getBreaker(key)
+	if b.State != breakerClosed {
+		log.Printf("🔌 Circuit closed for %s after %d failures", key, b.ConsecutiveFailures)
+	}
+	b.State = breakerClosed
+	b.ConsecutiveFailures = 0
+	b.LastSuccess = time.Now()
+	b.RetryAt = time.Time{}
+	b.probing = false
+}
+
+// recordFetchFailure applies exponential backoff based on the source's
+// refresh interval and opens the breaker after too many failures in a row
+func recordFetchFailure(key string, src FeedSource, err error) {
+	breakersMutex.Lock()
+	defer breakersMutex.Unlock()
+
+	now := time.Now()
+	b := getBreaker(key)
+	b.ConsecutiveFailures++
+	b.LastError = err.Error()
+	b.LastFailure = now
+	b.probing = false
+
+	if b.State == breakerHalfOpen || b.ConsecutiveFailures >= breakerFailureThreshold {
+		if b.State != breakerOpen {
+			log.Printf("🔌 Circuit open for %s after %d consecutive failures", key, b.ConsecutiveFailures)
+		}
+		b.State = breakerOpen
+		b.RetryAt = now.Add(breakerOpenTimeout)
+		return
+	}
+
+	b.RetryAt = now.Add(backoffDelay(src.refreshInterval(), b.ConsecutiveFailures))
+}
+
+// backoffDelay doubles the interval for each consecutive failure, capped at
+// breakerMaxBackoff or the interval itself when that is longer
+func backoffDelay(interval time.Duration, failures int) time.Duration {
+	limit := breakerMaxBackoff
+	if interval > limit {
+		limit = interval
+	}
+
+	delay := interval
+	for i := 0; i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// resetBreaker forgets the failure history of a source, used when its URL
+// changes or it is removed
+func resetBreaker(key string) {
+	breakersMutex.Lock()
+	delete(breakers, key)
+	breakersMutex.Unlock()
+}
+
+// SourceStatus is one entry of the /api/sources response
+type SourceStatus struct {
+	Key     string `json:"key"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Enabled bool   `json:"enabled"`
+	SourceBreaker
+}
+
+// sourcesStatusHandler lists every source with its circuit breaker state
+func sourcesStatusHandler(w http.ResponseWriter, r *http.Request) {
+	sources := snapshotSources()
+
+	breakersMutex.Lock()
+	statuses := make([]SourceStatus, 0, len(sources))
+	for key, src := range sources {
+		status := SourceStatus{
+			Key:           key,
+			Name:          src.Name,
+			URL:           src.URL,
+			Enabled:       src.Enabled,
+			SourceBreaker: SourceBreaker{State: breakerClosed},
+		}
+		if b, ok := breakers[key]; ok {
+			status.SourceBreaker = *b
+		}
+		statuses = append(statuses, status)
+	}
+	breakersMutex.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Key < statuses[j].Key
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(statuses)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	utf8BOM          = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM       = []byte{0xFF, 0xFE}
+	utf16BEBOM       = []byte{0xFE, 0xFF}
+	xmlPrologPattern = regexp.MustCompile(`^\s*<\?xml[^>]*\?>`)
+	xmlEncodingAttr  = regexp.MustCompile(`encoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+)
+
+// decodeFeedBody converts a feed body to UTF-8. The charset comes from a
+// UTF-16 byte order mark, then the Content-Type header, falling back to the
+// XML prolog. The prolog is rewritten to declare UTF-8, the only label
+// encoding/xml accepts on its own.
+func decodeFeedBody(body []byte, contentType string) ([]byte, error) {
+	body = bytes.TrimPrefix(body, utf8BOM)
+
+	label := contentTypeCharset(contentType)
+	switch {
+	case bytes.HasPrefix(body, utf16LEBOM):
+		body, label = body[len(utf16LEBOM):], "utf-16le"
+	case bytes.HasPrefix(body, utf16BEBOM):
+		body, label = body[len(utf16BEBOM):], "utf-16be"
+	}
+
+	// The prolog is only readable here for ASCII-compatible encodings
+	prolog := xmlPrologPattern.Find(body)
+	prologLabel := ""
+	if m := xmlEncodingAttr.FindSubmatch(prolog); m != nil {
+		prologLabel = string(m[1])
+	}
+
+	// Some servers send a blanket "charset=utf-8" for legacy feeds; trust the
+	// prolog when the body is clearly not UTF-8
+	if label == "" || (isUTF8Label(label) && prologLabel != "" && !utf8.Valid(body)) {
+		label = prologLabel
+	}
+
+	if label != "" && !isUTF8Label(label) {
+		enc, _ := charset.Lookup(label)
+		if enc == nil {
+			return nil, fmt.Errorf("unsupported charset %q", label)
+		}
+		decoded, err := enc.NewDecoder().Bytes(body)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %v", label, err)
+		}
+		body = decoded
+	}
+
+	// Rewritten after decoding so UTF-16 prologs are covered too; labels
+	// such as "us-ascii" and "utf8" need no transcoding but are still
+	// rejected by encoding/xml
+	body = xmlPrologPattern.ReplaceAllFunc(body, func(p []byte) []byte {
+		m := xmlEncodingAttr.FindSubmatch(p)
+		if m == nil || strings.EqualFold(string(m[1]), "utf-8") {
+			return p
+		}
+		return xmlEncodingAttr.ReplaceAll(p, []byte(`encoding="UTF-8"`))
+	})
+
+	return body, nil
+}
+
+// newXMLDecoder returns a decoder for a body already converted to UTF-8 by
+// decodeFeedBody. Its CharsetReader passes the input through, so a prolog
+// label that slipped past the rewrite cannot fail the parse.
+func newXMLDecoder(body []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder
+}
+
+// contentTypeCharset returns the charset parameter of a Content-Type header
+func contentTypeCharset(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(params["charset"])
+}
+
+// isUTF8Label reports whether text in label needs no transcoding to UTF-8
+func isUTF8Label(label string) bool {
+	switch strings.ToLower(label) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+const charsetTestItem = "Café résumé"
+
+func rssDocument(prolog, title string) string {
+	return prolog + `<rss version="2.0"><channel><title>Test</title><item><title>` + title + `</title></item></channel></rss>`
+}
+
+// utf16LE encodes s as UTF-16LE with a byte order mark
+func utf16LE(s string) []byte {
+	out := []byte{0xFF, 0xFE}
+	for _, unit := range utf16.Encode([]rune(s)) {
+		out = binary.LittleEndian.AppendUint16(out, unit)
+	}
+	return out
+}
+
+func TestParseFeedCharsets(t *testing.T) {
+	latin1Title := "Caf\xe9 r\xe9sum\xe9"
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+	}{
+		{
+			name:        "header charset",
+			body:        []byte(rssDocument(`<?xml version="1.0"?>`, latin1Title)),
+			contentType: "application/rss+xml; charset=windows-1252",
+			want:        charsetTestItem,
+		},
+		{
+			name: "prolog charset",
+			body: []byte(rssDocument(`<?xml version="1.0" encoding="ISO-8859-1"?>`, latin1Title)),
+			want: charsetTestItem,
+		},
+		{
+			name:        "us-ascii prolog",
+			body:        []byte(rssDocument(`<?xml version="1.0" encoding="us-ascii"?>`, "Plain title")),
+			contentType: "application/rss+xml",
+			want:        "Plain title",
+		},
+		{
+			name: "utf8 prolog",
+			body: []byte(rssDocument(`<?xml version="1.0" encoding="utf8"?>`, charsetTestItem)),
+			want: charsetTestItem,
+		},
+		{
+			name:        "mislabeled utf-8 header",
+			body:        []byte(rssDocument(`<?xml version="1.0" encoding="windows-1252"?>`, latin1Title)),
+			contentType: "text/xml; charset=utf-8",
+			want:        charsetTestItem,
+		},
+		{
+			name: "utf-16 with byte order mark",
+			body: utf16LE(rssDocument(`<?xml version="1.0" encoding="UTF-16"?>`, charsetTestItem)),
+			want: charsetTestItem,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := parseFeed(tt.body, tt.contentType, "")
+			if err != nil {
+				t.Fatalf("parseFeed: %v", err)
+			}
+			if len(feed.Items) != 1 || feed.Items[0].Title != tt.want {
+				t.Fatalf("items = %+v, want one titled %q", feed.Items, tt.want)
+			}
+		})
+	}
+}
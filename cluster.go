@@ -0,0 +1,258 @@
+package main
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Near-duplicate detection uses MinHash signatures over word shingles with
+// locality-sensitive hashing to find candidate pairs. lshBands*lshRows must
+// equal minhashSize.
+const (
+	minhashSize                = 64
+	lshBands                   = 16
+	lshRows                    = minhashSize / lshBands
+	shingleSize                = 3
+	clusterSimilarityThreshold = 0.6
+)
+
+// AlternateSource is another outlet carrying the same story
+type AlternateSource struct {
+	ID         string `json:"id"`
+	Source     string `json:"source"`
+	SourceName string `json:"source_name"`
+	Title      string `json:"title"`
+	Link       string `json:"link"`
+}
+
+// StoryCluster groups near-duplicate items. ID is the ID of the earliest
+// published member so it stays stable as later copies join; PrimaryID is
+// the highest ranked member.
+type StoryCluster struct {
+	ID         string            `json:"id"`
+	PrimaryID  string            `json:"primary_id"`
+	Title      string            `json:"title"`
+	Size       int               `json:"size"`
+	Alternates []AlternateSource `json:"alternates"`
+}
+
+var minhashSeeds = func() [minhashSize]uint64 {
+	var seeds [minhashSize]uint64
+	state := uint64(0x9E3779B97F4A7C15)
+	for i := range seeds {
+		state += 0x9E3779B97F4A7C15
+		seeds[i] = mix64(state)
+	}
+	return seeds
+}()
+
+// mix64 is the splitmix64 finalizer
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xBF58476D1CE4E5B9
+	x ^= x >> 27
+	x *= 0x94D049BB133111EB
+	x ^= x >> 31
+	return x
+}
+
+// shingles hashes the overlapping word n-grams of a text
+func shingles(text string) []uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	n := shingleSize
+	if len(words) < n {
+		n = len(words)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	hashes := make([]uint64, 0, len(words)-n+1)
+	for i := 0; i+n <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+n], " ")))
+		hashes = append(hashes, h.Sum64())
+	}
+	return hashes
+}
+
+func minhashSignature(hashes []uint64) [minhashSize]uint64 {
+	var sig [minhashSize]uint64
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for _, h := range hashes {
+		for i, seed := range minhashSeeds {
+			if v := mix64(h ^ seed); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// signatureSimilarity estimates the Jaccard similarity of two shingle sets
+func signatureSimilarity(a, b *[minhashSize]uint64) float64 {
+	matches := 0
+	for i := range a {
+		if a[i] == b[i] {
+			matches++
+		}
+	}
+	return float64(matches) / minhashSize
+}
+
+// clusterItems groups near-duplicate items from different sources by title
+// and description; a cluster holds at most one item per source. It sets
+// ClusterID on every clustered item and Alternates on each primary, and
+// returns clusters with more than one member, largest first.
+func clusterItems(items []NewsItem) []StoryCluster {
+	sigs := make([][minhashSize]uint64, len(items))
+	empty := make([]bool, len(items))
+	for i, item := range items {
+		hashes := shingles(item.Title + " " + item.Description)
+		empty[i] = len(hashes) == 0
+		sigs[i] = minhashSignature(hashes)
+	}
+
+	// Each component tracks its sources: two items from the same feed are
+	// never the same story, however alike their text (feeds such as NSE
+	// disclosures share boilerplate descriptions)
+	parent := make([]int, len(items))
+	sources := make([]map[string]bool, len(items))
+	for i := range parent {
+		parent[i] = i
+		sources[i] = map[string]bool{items[i].Source: true}
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	// Items sharing any band are candidates; confirm with the full signature
+	for band := 0; band < lshBands; band++ {
+		buckets := make(map[uint64][]int)
+		for i := range items {
+			if empty[i] {
+				continue
+			}
+			h := uint64(band)
+			for _, v := range sigs[i][band*lshRows : (band+1)*lshRows] {
+				h = mix64(h ^ v)
+			}
+			buckets[h] = append(buckets[h], i)
+		}
+
+		for _, members := range buckets {
+			for x := 0; x < len(members); x++ {
+				for y := x + 1; y < len(members); y++ {
+					a, b := find(members[x]), find(members[y])
+					if a == b {
+						continue
+					}
+					if sharesSource(sources[a], sources[b]) {
+						continue
+					}
+					if signatureSimilarity(&sigs[members[x]], &sigs[members[y]]) >= clusterSimilarityThreshold {
+						parent[b] = a
+						for src := range sources[b] {
+							sources[a][src] = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range items {
+		items[i].ClusterID = ""
+		items[i].Alternates = nil
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+
+	var clusters []StoryCluster
+	for _, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+
+		primary, earliest := members[0], members[0]
+		for _, m := range members[1:] {
+			if items[m].Priority > items[primary].Priority ||
+				(items[m].Priority == items[primary].Priority && items[m].PubDate.Before(items[primary].PubDate)) {
+				primary = m
+			}
+			if items[m].PubDate.Before(items[earliest].PubDate) {
+				earliest = m
+			}
+		}
+
+		cluster := StoryCluster{
+			ID:        items[earliest].ID,
+			PrimaryID: items[primary].ID,
+			Title:     items[primary].Title,
+			Size:      len(members),
+		}
+		for _, m := range members {
+			items[m].ClusterID = cluster.ID
+			if m == primary {
+				continue
+			}
+			cluster.Alternates = append(cluster.Alternates, AlternateSource{
+				ID:         items[m].ID,
+				Source:     items[m].Source,
+				SourceName: items[m].SourceName,
+				Title:      items[m].Title,
+				Link:       items[m].Link,
+			})
+		}
+		items[primary].Alternates = cluster.Alternates
+		clusters = append(clusters, cluster)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Size == clusters[j].Size {
+			return clusters[i].ID < clusters[j].ID
+		}
+		return clusters[i].Size > clusters[j].Size
+	})
+	return clusters
+}
+
+// collapseClusters drops the non-primary members of each cluster, keeping
+// the order of the remaining items
+func collapseClusters(items []NewsItem, clusters []StoryCluster) []NewsItem {
+	secondary := make(map[string]bool)
+	for _, cluster := range clusters {
+		for _, alt := range cluster.Alternates {
+			secondary[alt.ID] = true
+		}
+	}
+
+	collapsed := make([]NewsItem, 0, len(items))
+	for _, item := range items {
+		if !secondary[item.ID] {
+			collapsed = append(collapsed, item)
+		}
+	}
+	return collapsed
+}
+
+func sharesSource(a, b map[string]bool) bool {
+	for src := range a {
+		if b[src] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+const disclosureText = "Disclosure under Regulation 7(2) of SEBI (Prohibition of Insider Trading) Regulations, 2015 received from the company regarding acquisition of shares by a designated person"
+
+func TestClusterItemsKeepsSameSourceItemsSeparate(t *testing.T) {
+	now := time.Now()
+	items := []NewsItem{
+		{ID: "1", Source: "NSE_IT", Title: "Reliance Industries Limited", Description: disclosureText, PubDate: now},
+		{ID: "2", Source: "NSE_IT", Title: "Tata Motors Limited", Description: disclosureText, PubDate: now},
+		{ID: "3", Source: "NSE_IT", Title: "Infosys Limited", Description: disclosureText, PubDate: now},
+	}
+
+	if clusters := clusterItems(items); len(clusters) != 0 {
+		t.Fatalf("got %d clusters, want none for same-source items", len(clusters))
+	}
+	if got := collapseClusters(items, nil); len(got) != 3 {
+		t.Fatalf("collapsed to %d items, want 3", len(got))
+	}
+}
+
+func TestClusterItemsMergesCrossSourceDuplicates(t *testing.T) {
+	now := time.Now()
+	story := "RBI keeps repo rate unchanged at 6.5 percent for the sixth straight meeting citing sticky food inflation"
+	items := []NewsItem{
+		{ID: "1", Source: "ZP", Title: "RBI holds repo rate", Description: story, PubDate: now},
+		{ID: "2", Source: "BS_NEWS", Title: "RBI holds repo rate", Description: story, PubDate: now.Add(time.Minute)},
+		{ID: "3", Source: "ZP", Title: "RBI holds repo rate again", Description: story, PubDate: now.Add(2 * time.Minute)},
+	}
+
+	clusters := clusterItems(items)
+	if len(clusters) != 1 || clusters[0].Size != 2 {
+		t.Fatalf("clusters = %+v, want one cluster of two", clusters)
+	}
+	sources := map[string]bool{}
+	for _, item := range items {
+		if item.ClusterID == clusters[0].ID {
+			if sources[item.Source] {
+				t.Fatalf("cluster has two items from %s", item.Source)
+			}
+			sources[item.Source] = true
+		}
+	}
+}
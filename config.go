@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Config holds the runtime settings. Values are resolved in increasing order
+// of precedence: built-in defaults, the JSON config file, environment
+// variables, then command-line flags.
+type Config struct {
+	ListenAddr            string   `json:"listen_addr"`
+	TemplatePath          string   `json:"template_path"`
+	SourcesFile           string   `json:"sources_file"`
+	Timezone              string   `json:"timezone"`
+	RefreshInterval       Duration `json:"refresh_interval"`
+	MaxArticlesPerSource  int      `json:"max_articles_per_source"`
+	MaxTotalArticles      int      `json:"max_total_articles"`
+	MemoryCleanupInterval Duration `json:"memory_cleanup_interval"`
+	WebSocketTimeout      Duration `json:"websocket_timeout"`
+	StorePath             string   `json:"store_path"`
+	Retention             Duration `json:"retention"`
+	OPMLImportToken       string   `json:"opml_import_token"`
+}
+
+// appConfig is set once in main before any goroutine starts
+var appConfig = defaultConfig()
+
+func defaultConfig() Config {
+	return Config{
+		ListenAddr:            ":8080",
+		TemplatePath:          "template.html",
+		SourcesFile:           "sources.json",
+		Timezone:              "Asia/Kolkata",
+		RefreshInterval:       Duration(5 * time.Minute),
+		MaxArticlesPerSource:  10,  // Limit articles per source
+		MaxTotalArticles:      150, // Total articles limit (15 sources × 10)
+		MemoryCleanupInterval: Duration(time.Minute),
+		WebSocketTimeout:      Duration(60 * time.Second),
+		StorePath:             "data/articles.db",
+		Retention:             Duration(7 * 24 * time.Hour),
+	}
+}
+
+// configSetting binds one setting to its environment variable and flag
+type configSetting struct {
+	Env   string
+	Flag  string
+	Usage string
+	Set   func(cfg *Config, value string) error
+}
+
+var configSettings = []configSetting{
+	{"LISTEN_ADDR", "listen", "HTTP listen address", func(c *Config, v string) error {
+		c.ListenAddr = v
+		return nil
+	}},
+	{"TEMPLATE_PATH", "template", "path to the HTML template", func(c *Config, v string) error {
+		c.TemplatePath = v
+		return nil
+	}},
+	{"RSS_SOURCES_FILE", "sources", "path to the JSON sources file", func(c *Config, v string) error {
+		c.SourcesFile = v
+		return nil
+	}},
+	{"TZ", "timezone", "IANA timezone for displayed times", func(c *Config, v string) error {
+		c.Timezone = v
+		return nil
+	}},
+	{"RSS_REFRESH_INTERVAL", "refresh-interval", "default refresh interval per source", func(c *Config, v string) error {
+		return setDuration(&c.RefreshInterval, v)
+	}},
+	{"MAX_ARTICLES_PER_SOURCE", "max-articles-per-source", "articles kept per source", func(c *Config, v string) error {
+		return setInt(&c.MaxArticlesPerSource, v)
+	}},
+	{"MAX_TOTAL_ARTICLES", "max-total-articles", "articles kept in total", func(c *Config, v string) error {
+		return setInt(&c.MaxTotalArticles, v)
+	}},
+	{"MEMORY_CLEANUP_INTERVAL", "memory-cleanup-interval", "interval between forced garbage collections", func(c *Config, v string) error {
+		return setDuration(&c.MemoryCleanupInterval, v)
+	}},
+	{"WEBSOCKET_TIMEOUT", "websocket-timeout", "WebSocket write and idle timeout", func(c *Config, v string) error {
+		return setDuration(&c.WebSocketTimeout, v)
+	}},
+	{"ARTICLE_STORE_PATH", "store", "path to the article database, empty for in-memory", func(c *Config, v string) error {
+		c.StorePath = v
+		return nil
+	}},
+	{"ARTICLE_RETENTION", "retention", "how long stored articles are kept", func(c *Config, v string) error {
+		return setDuration(&c.Retention, v)
+	}},
+	{"OPML_IMPORT_TOKEN", "opml-import-token", "bearer token for OPML import over HTTP; import is disabled when empty", func(c *Config, v string) error {
+		c.OPMLImportToken = v
+		return nil
+	}},
+}
+
+func setDuration(d *Duration, value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func setInt(i *int, value string) error {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*i = parsed
+	return nil
+}
+
+// loadConfig resolves the configuration from defaults, the config file
+// (-config or RSS_CONFIG_FILE), the environment and args, then validates it
+func loadConfig(args []string) (Config, error) {
+	cfg := defaultConfig()
+
+	fs := flag.NewFlagSet("rss-aggregator", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("RSS_CONFIG_FILE"), "path to a JSON config file")
+	flagValues := make(map[string]*string, len(configSettings))
+	for _, s := range configSettings {
+		flagValues[s.Flag] = fs.String(s.Flag, "", fmt.Sprintf("%s (env %s)", s.Usage, s.Env))
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *configPath != "" {
+		data, err := os.ReadFile(*configPath)
+		if err != nil {
+			return cfg, err
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("parsing %s: %v", *configPath, err)
+		}
+	}
+
+	var errs []error
+	for _, s := range configSettings {
+		if value, ok := os.LookupEnv(s.Env); ok && value != "" {
+			if err := s.Set(&cfg, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", s.Env, err))
+			}
+		}
+	}
+
+	// Only flags given on the command line override the other layers
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range configSettings {
+			if s.Flag == f.Name {
+				if err := s.Set(&cfg, *flagValues[f.Name]); err != nil {
+					errs = append(errs, fmt.Errorf("-%s: %v", f.Name, err))
+				}
+			}
+		}
+	})
+
+	if len(errs) > 0 {
+		return cfg, errors.Join(errs...)
+	}
+	return cfg, cfg.validate()
+}
+
+func (c Config) validate() error {
+	var errs []error
+	if c.ListenAddr == "" {
+		errs = append(errs, fmt.Errorf("listen address must not be empty"))
+	}
+	if c.TemplatePath == "" {
+		errs = append(errs, fmt.Errorf("template path must not be empty"))
+	} else if _, err := os.Stat(c.TemplatePath); err != nil {
+		errs = append(errs, fmt.Errorf("template: %v", err))
+	}
+	if c.SourcesFile == "" {
+		errs = append(errs, fmt.Errorf("sources file path must not be empty"))
+	}
+	if c.Timezone == "" {
+		errs = append(errs, fmt.Errorf("timezone must not be empty"))
+	}
+	if time.Duration(c.RefreshInterval) < minSourceRefreshInterval {
+		errs = append(errs, fmt.Errorf("refresh interval must be at least %s", minSourceRefreshInterval))
+	}
+	if c.MaxArticlesPerSource < 1 {
+		errs = append(errs, fmt.Errorf("max articles per source must be positive"))
+	}
+	if c.MaxTotalArticles < c.MaxArticlesPerSource {
+		errs = append(errs, fmt.Errorf("max total articles must be at least max articles per source"))
+	}
+	if c.MemoryCleanupInterval < 0 {
+		errs = append(errs, fmt.Errorf("memory cleanup interval must not be negative"))
+	}
+	if time.Duration(c.WebSocketTimeout) < time.Second {
+		errs = append(errs, fmt.Errorf("websocket timeout must be at least 1s"))
+	}
+	if time.Duration(c.Retention) < 24*time.Hour {
+		errs = append(errs, fmt.Errorf("retention must be at least 24h to cover the real-time view"))
+	}
+	return errors.Join(errs...)
+}
+
+// applyConfig installs a validated configuration. A timezone that cannot be
+// loaded (for example when tzdata is missing) falls back to local time.
+func applyConfig(cfg Config) {
+	appConfig = cfg
+
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		log.Printf("Warning: Could not load timezone %s, using local time: %v", cfg.Timezone, err)
+		loc = time.Local
+	}
+	istLocation = loc
+}
+
+// staleDataThreshold is how long without a successful fetch before
+// /api/status reports the data as stale
+func staleDataThreshold() time.Duration {
+	return 3 * time.Duration(appConfig.RefreshInterval)
+}
+
+// memoryCleanupLoop forces a garbage collection every MemoryCleanupInterval.
+// A zero interval disables it.
+func memoryCleanupLoop() {
+	interval := time.Duration(appConfig.MemoryCleanupInterval)
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		runtime.GC()
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// LiveMessage is the envelope for every WebSocket update. Seq is the hub
+// event ID: it increases by one per broadcast, so a client that sees a jump
+// has missed an update and should send {"action": "resync"}. Messages with
+// a seq at or below the last snapshot's are already reflected in it. Epoch
+// identifies the server process; a client reconnects with
+// ?since=<epoch>.<seq>, and seq values from different epochs are unrelated.
+type LiveMessage struct {
+	Type  string          `json:"type"` // "snapshot" or "delta"
+	Epoch string          `json:"epoch"`
+	Seq   uint64          `json:"seq"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// NewsDelta describes how the live view changed since the previous
+// broadcast. Items are keyed by article ID; analytics and sentiment hold
+// only the fields whose values changed.
+type NewsDelta struct {
+	Added        []NewsItem                 `json:"added,omitempty"`
+	Updated      []NewsItem                 `json:"updated,omitempty"`
+	Removed      []string                   `json:"removed,omitempty"`
+	Analytics    map[string]json.RawMessage `json:"analytics,omitempty"`
+	Sentiment    map[string]json.RawMessage `json:"sentiment,omitempty"`
+	Clusters     []StoryCluster             `json:"clusters,omitempty"`
+	LastUpdated  string                     `json:"last_updated,omitempty"`
+	TotalSources int                        `json:"total_sources,omitempty"`
+}
+
+// The last broadcast payload, which the next delta is computed against
+var (
+	lastBroadcast      NewsData
+	lastBroadcastMutex sync.Mutex
+)
+
+// nextDelta returns the changes from the previous broadcast to data and
+// records data as the new baseline
+func nextDelta(data NewsData) NewsDelta {
+	lastBroadcastMutex.Lock()
+	defer lastBroadcastMutex.Unlock()
+
+	delta := diffNewsData(lastBroadcast, data)
+	lastBroadcast = data
+	return delta
+}
+
+func diffNewsData(prev, next NewsData) NewsDelta {
+	var delta NewsDelta
+
+	previous := make(map[string]NewsItem, len(prev.Items))
+	for _, item := range prev.Items {
+		previous[item.ID] = item
+	}
+	for _, item := range next.Items {
+		old, ok := previous[item.ID]
+		switch {
+		case !ok:
+			delta.Added = append(delta.Added, item)
+		case itemChanged(old, item):
+			delta.Updated = append(delta.Updated, item)
+		}
+		delete(previous, item.ID)
+	}
+	for id := range previous {
+		delta.Removed = append(delta.Removed, id)
+	}
+
+	delta.Analytics = diffFields(prev.Analytics, next.Analytics)
+	delta.Sentiment = diffFields(prev.Sentiment, next.Sentiment)
+	if !reflect.DeepEqual(prev.Clusters, next.Clusters) {
+		delta.Clusters = next.Clusters
+	}
+	if prev.LastUpdated != next.LastUpdated {
+		delta.LastUpdated = next.LastUpdated
+	}
+	if prev.TotalSources != next.TotalSources {
+		delta.TotalSources = next.TotalSources
+	}
+	return delta
+}
+
+// itemChanged ignores TimeAgo, which clients derive from pub_date
+func itemChanged(a, b NewsItem) bool {
+	a.TimeAgo, b.TimeAgo = "", ""
+	return !reflect.DeepEqual(a, b)
+}
+
+// diffFields returns the JSON fields of next whose encoding differs from prev
+func diffFields(prev, next interface{}) map[string]json.RawMessage {
+	var before, after map[string]json.RawMessage
+	if data, err := json.Marshal(prev); err == nil {
+		json.Unmarshal(data, &before)
+	}
+	if data, err := json.Marshal(next); err == nil {
+		json.Unmarshal(data, &after)
+	}
+
+	changed := make(map[string]json.RawMessage)
+	for field, value := range after {
+		if !bytes.Equal(before[field], value) {
+			changed[field] = value
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return changed
+}
+
+// filterDelta narrows a delta to the items matching f. An updated item that
+// no longer matches is reported as removed; one that has started matching
+// appears in updated, so clients should treat updates as upserts.
+func filterDelta(delta NewsDelta, f *ItemFilter) NewsDelta {
+	filtered := delta
+	filtered.Added, filtered.Updated = nil, nil
+	filtered.Removed = append([]string(nil), delta.Removed...)
+
+	for _, item := range delta.Added {
+		if f.Match(item) {
+			filtered.Added = append(filtered.Added, item)
+		}
+	}
+	for _, item := range delta.Updated {
+		if f.Match(item) {
+			filtered.Updated = append(filtered.Updated, item)
+		} else {
+			filtered.Removed = append(filtered.Removed, item.ID)
+		}
+	}
+	return filtered
+}
+
+// encodeLiveMessage wraps an encoded payload in the WebSocket envelope
+func encodeLiveMessage(messageType string, seq uint64, data []byte) ([]byte, error) {
+	return json.Marshal(LiveMessage{Type: messageType, Epoch: broadcastHub.Epoch(), Seq: seq, Data: data})
+}
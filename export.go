@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rows are flushed to the client in batches of this size
+const exportFlushEvery = 100
+
+var articleCSVHeader = []string{
+	"id", "pub_date", "source", "source_name", "category", "title", "link",
+	"description", "summary", "sentiment_label", "sentiment_score", "priority",
+	"has_nifty50", "nifty50_stock", "keywords", "cluster_id",
+}
+
+// SourceRow is one row of the exported source table
+type SourceRow struct {
+	SourceName  string  `json:"source_name"`
+	Articles    int     `json:"articles"`
+	Reliability float64 `json:"reliability"`
+}
+
+// exportWriter streams rows as CSV or newline-delimited JSON
+type exportWriter struct {
+	w       http.ResponseWriter
+	csv     *csv.Writer
+	json    *json.Encoder
+	flusher http.Flusher
+	rows    int
+}
+
+func newExportWriter(w http.ResponseWriter, name, format string) *exportWriter {
+	ew := &exportWriter{w: w}
+	ew.flusher, _ = w.(http.Flusher)
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		ew.csv = csv.NewWriter(w)
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		ew.json = json.NewEncoder(w)
+	}
+	return ew
+}
+
+// Write emits one row; record is used for CSV and value for NDJSON
+func (ew *exportWriter) Write(record []string, value interface{}) error {
+	var err error
+	if ew.csv != nil {
+		err = ew.csv.Write(record)
+	} else {
+		err = ew.json.Encode(value)
+	}
+	if ew.rows++; ew.rows%exportFlushEvery == 0 {
+		ew.Flush()
+	}
+	return err
+}
+
+func (ew *exportWriter) Flush() {
+	if ew.csv != nil {
+		ew.csv.Flush()
+	}
+	if ew.flusher != nil {
+		ew.flusher.Flush()
+	}
+}
+
+// exportHandler serves /api/export/{articles,keywords,sources}.{csv,ndjson}.
+// All exports honor the /filter parameters and since/until; keyword and
+// source tables come from live analytics when no parameters are given.
+func exportHandler(w http.ResponseWriter, r *http.Request) {
+	file := path.Base(r.URL.Path)
+	name, format, _ := strings.Cut(file, ".")
+	if format != "csv" && format != "ndjson" {
+		apiNotFoundHandler(w, r)
+		return
+	}
+
+	var export func(ew *exportWriter, items []NewsItem) error
+	switch name {
+	case "articles":
+		export = exportArticles
+	case "keywords":
+		export = exportKeywords
+	case "sources":
+		export = exportSources
+	default:
+		apiNotFoundHandler(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := parseItemFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	since, until, err := parseTimeRange(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var items []NewsItem
+	if name == "articles" || len(query) > 0 {
+		stored, err := articleStore.Range(since, until)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		items = make([]NewsItem, 0, len(stored))
+		for _, item := range stored {
+			item.Priority = calculatePriority(item, time.Now())
+			if filter.Match(item) {
+				items = append(items, item)
+			}
+		}
+	}
+
+	ew := newExportWriter(w, name, format)
+	if err := export(ew, items); err != nil {
+		// Headers are already sent; the client sees a truncated body
+		return
+	}
+	ew.Flush()
+}
+
+func exportArticles(ew *exportWriter, items []NewsItem) error {
+	if ew.csv != nil {
+		if err := ew.Write(articleCSVHeader, nil); err != nil {
+			return err
+		}
+	}
+	for _, item := range items {
+		record := []string{
+			item.ID,
+			item.PubDate.Format(time.RFC3339),
+			item.Source,
+			item.SourceName,
+			item.Category,
+			item.Title,
+			item.Link,
+			item.Description,
+			item.Summary,
+			item.SentimentLabel,
+			strconv.FormatFloat(item.SentimentScore, 'f', -1, 64),
+			strconv.Itoa(item.Priority),
+			strconv.FormatBool(item.HasNifty50),
+			item.Nifty50Stock,
+			strings.Join(item.Keywords, ";"),
+			item.ClusterID,
+		}
+		if err := ew.Write(record, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportAnalytics returns analytics for the filtered items, or the live
+// analytics when items is nil because the request had no parameters
+func exportAnalytics(items []NewsItem) NewsAnalytics {
+	if items == nil {
+		newsMutex.RLock()
+		defer newsMutex.RUnlock()
+		return liveAnalytics
+	}
+	return generateAnalytics(items)
+}
+
+func exportKeywords(ew *exportWriter, items []NewsItem) error {
+	if ew.csv != nil {
+		if err := ew.Write([]string{"keyword", "count"}, nil); err != nil {
+			return err
+		}
+	}
+	for _, kw := range exportAnalytics(items).TopKeywords {
+		if err := ew.Write([]string{kw.Keyword, strconv.Itoa(kw.Count)}, kw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportSources(ew *exportWriter, items []NewsItem) error {
+	analytics := exportAnalytics(items)
+
+	var rows []SourceRow
+	for name, count := range analytics.SourceCount {
+		rows = append(rows, SourceRow{
+			SourceName:  name,
+			Articles:    count,
+			Reliability: analytics.SourceReliability[name],
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Articles != rows[j].Articles {
+			return rows[i].Articles > rows[j].Articles
+		}
+		return rows[i].SourceName < rows[j].SourceName
+	})
+
+	if ew.csv != nil {
+		if err := ew.Write([]string{"source_name", "articles", "reliability"}, nil); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		record := []string{row.SourceName, strconv.Itoa(row.Articles), strconv.FormatFloat(row.Reliability, 'f', -1, 64)}
+		if err := ew.Write(record, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+// Feed is the common item model every feed parser produces
+type Feed struct {
+	Title string
+	Items []Item
+}
+
+// FeedParser describes one input format. Parsers are selected by the format
+// declared on a source, then by Content-Type, then by the document root element.
+type FeedParser struct {
+	Name         string
+	ContentTypes []string
+	RootElements []string
+	Parse        func(body []byte) (*Feed, error)
+}
+
+var feedParsers = map[string]FeedParser{}
+
+// registerFeedParser adds a parser to the registry, replacing any parser
+// already registered under the same name
+func registerFeedParser(parser FeedParser) {
+	feedParsers[parser.Name] = parser
+}
+
+func init() {
+	registerFeedParser(FeedParser{
+		Name:         "rss",
+		ContentTypes: []string{"application/rss+xml"},
+		RootElements: []string{"rss"},
+		Parse:        parseRSS,
+	})
+	registerFeedParser(FeedParser{
+		Name:         "atom",
+		ContentTypes: []string{"application/atom+xml"},
+		RootElements: []string{"feed"},
+		Parse:        parseAtom,
+	})
+	registerFeedParser(FeedParser{
+		Name:         "rdf",
+		ContentTypes: []string{"application/rdf+xml"},
+		RootElements: []string{"RDF"},
+		Parse:        parseRDF,
+	})
+	registerFeedParser(FeedParser{
+		Name:         "json",
+		ContentTypes: []string{"application/feed+json", "application/json"},
+		Parse:        parseJSONFeed,
+	})
+}
+
+// RSS 1.0 (RDF) feed structures
+type RDF struct {
+	Channel RDFChannel `xml:"channel"`
+	Items   []RDFItem  `xml:"item"`
+}
+
+type RDFChannel struct {
+	Title string `xml:"title"`
+}
+
+type RDFItem struct {
+	About       string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Subject     string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+}
+
+// Atom 1.0 feed structures
+type AtomFeed struct {
+	Title   string      `xml:"title"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      AtomText       `xml:"title"`
+	Links      []AtomLink     `xml:"link"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Summary    AtomText       `xml:"summary"`
+	Content    AtomText       `xml:"content"`
+	Categories []AtomCategory `xml:"category"`
+}
+
+type AtomText struct {
+	Type     string `xml:"type,attr"`
+	Text     string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type AtomCategory struct {
+	Term   string `xml:"term,attr"`
+	Scheme string `xml:"scheme,attr,omitempty"`
+	Label  string `xml:"label,attr"`
+}
+
+// String returns the text of an Atom text construct. XHTML content is kept
+// as markup so cleanDescription can strip it like RSS descriptions.
+func (t AtomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.InnerXML)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+// JSON Feed 1.1 structures
+type JSONFeed struct {
+	Version string         `json:"version"`
+	Title   string         `json:"title"`
+	Items   []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	ExternalURL   string   `json:"external_url"`
+	Title         string   `json:"title"`
+	ContentHTML   string   `json:"content_html"`
+	ContentText   string   `json:"content_text"`
+	Summary       string   `json:"summary"`
+	DatePublished string   `json:"date_published"`
+	DateModified  string   `json:"date_modified"`
+	Tags          []string `json:"tags"`
+}
+
+// detectRootElement returns the local name of the first element in an XML document
+func detectRootElement(body []byte) (string, error) {
+	decoder := newXMLDecoder(body)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", fmt.Errorf("no root element found")
+		}
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// parseFeed picks a parser for the document and normalizes it into a Feed.
+// format is the format declared by the source; empty or "auto" detects it.
+func parseFeed(body []byte, contentType, format string) (*Feed, error) {
+	body, err := decodeFeedBody(body, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	parser, err := selectFeedParser(body, contentType, format)
+	if err != nil {
+		return nil, err
+	}
+	return parser.Parse(body)
+}
+
+func selectFeedParser(body []byte, contentType, format string) (FeedParser, error) {
+	if format != "" && format != "auto" {
+		parser, ok := feedParsers[format]
+		if !ok {
+			return FeedParser{}, fmt.Errorf("unknown feed format %q", format)
+		}
+		return parser, nil
+	}
+
+	// JSON documents have no root element, so sniff them before the XML path
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		return feedParsers["json"], nil
+	}
+
+	// The root element is more reliable than Content-Type, which many
+	// publishers set to a generic or wrong XML type
+	root, rootErr := detectRootElement(body)
+	if rootErr == nil {
+		for _, parser := range feedParsers {
+			for _, element := range parser.RootElements {
+				if element == root {
+					return parser, nil
+				}
+			}
+		}
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		for _, parser := range feedParsers {
+			for _, ct := range parser.ContentTypes {
+				if ct == mediaType {
+					return parser, nil
+				}
+			}
+		}
+	}
+
+	if rootErr != nil {
+		return FeedParser{}, rootErr
+	}
+	return FeedParser{}, fmt.Errorf("unsupported feed format: <%s>", root)
+}
+
+func parseRSS(body []byte) (*Feed, error) {
+	var rss RSS
+	if err := newXMLDecoder(body).Decode(&rss); err != nil {
+		return nil, err
+	}
+	return &Feed{Title: rss.Channel.Title, Items: rss.Channel.Items}, nil
+}
+
+func parseRDF(body []byte) (*Feed, error) {
+	var rdf RDF
+	if err := newXMLDecoder(body).Decode(&rdf); err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Title: rdf.Channel.Title,
+		Items: make([]Item, 0, len(rdf.Items)),
+	}
+	for _, item := range rdf.Items {
+		feed.Items = append(feed.Items, Item{
+			Title:       strings.TrimSpace(item.Title),
+			Link:        strings.TrimSpace(item.Link),
+			Description: item.Description,
+			PubDate:     item.Date,
+			Category:    item.Subject,
+			GUID:        GUID{Value: strings.TrimSpace(item.About)},
+		})
+	}
+	return feed, nil
+}
+
+func parseAtom(body []byte) (*Feed, error) {
+	var atom AtomFeed
+	if err := newXMLDecoder(body).Decode(&atom); err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Title: atom.Title,
+		Items: make([]Item, 0, len(atom.Entries)),
+	}
+	for _, entry := range atom.Entries {
+		description := entry.Summary.String()
+		if description == "" {
+			description = entry.Content.String()
+		}
+
+		pubDate := entry.Published
+		if pubDate == "" {
+			pubDate = entry.Updated
+		}
+
+		var category string
+		if len(entry.Categories) > 0 {
+			category = entry.Categories[0].Term
+			if category == "" {
+				category = entry.Categories[0].Label
+			}
+		}
+
+		feed.Items = append(feed.Items, Item{
+			Title:       entry.Title.String(),
+			Link:        atomEntryLink(entry.Links),
+			Description: description,
+			PubDate:     pubDate,
+			Category:    category,
+			GUID:        GUID{Value: strings.TrimSpace(entry.ID), IsPermaLink: "false"},
+		})
+	}
+	return feed, nil
+}
+
+// atomEntryLink picks the alternate link of an entry, preferring HTML pages.
+// A link without a rel attribute is an alternate link per RFC 4287.
+func atomEntryLink(links []AtomLink) string {
+	var alternate string
+	for _, link := range links {
+		if link.Rel != "" && link.Rel != "alternate" {
+			continue
+		}
+		if link.Type == "" || link.Type == "text/html" {
+			return link.Href
+		}
+		if alternate == "" {
+			alternate = link.Href
+		}
+	}
+	if alternate == "" && len(links) > 0 {
+		alternate = links[0].Href
+	}
+	return alternate
+}
+
+func parseJSONFeed(body []byte) (*Feed, error) {
+	var jf JSONFeed
+	if err := json.Unmarshal(body, &jf); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(jf.Version, "https://jsonfeed.org/version/") {
+		return nil, fmt.Errorf("not a JSON Feed: version %q", jf.Version)
+	}
+
+	feed := &Feed{
+		Title: jf.Title,
+		Items: make([]Item, 0, len(jf.Items)),
+	}
+	for _, item := range jf.Items {
+		link := item.URL
+		if link == "" {
+			link = item.ExternalURL
+		}
+
+		description := item.Summary
+		if description == "" {
+			description = item.ContentHTML
+		}
+		if description == "" {
+			description = item.ContentText
+		}
+
+		pubDate := item.DatePublished
+		if pubDate == "" {
+			pubDate = item.DateModified
+		}
+
+		var category string
+		if len(item.Tags) > 0 {
+			category = item.Tags[0]
+		}
+
+		feed.Items = append(feed.Items, Item{
+			Title:       item.Title,
+			Link:        link,
+			Description: description,
+			PubDate:     pubDate,
+			Category:    category,
+			GUID:        GUID{Value: item.ID, IsPermaLink: "false"},
+		})
+	}
+	return feed, nil
+}
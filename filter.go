@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ItemFilter is a parsed set of article predicates. It is shared by the
+// HTTP filter API and WebSocket subscriptions so both accept the same
+// parameters:
+//
+//	source=ZP,NSE_IT   category=!Crypto   sentiment=Positive
+//	stock=TCS,INFY     keyword=rbi        nifty50=true
+//	min_priority=3     min_sentiment=0.2
+//
+// Every list parameter is comma-separated and case-insensitive; a value
+// prefixed with '!' excludes matches instead.
+type ItemFilter struct {
+	Sources      valueSet
+	Categories   valueSet
+	Sentiments   valueSet
+	Stocks       valueSet
+	Keywords     valueSet
+	Nifty50Only  bool
+	MinPriority  *int
+	MinSentiment *float64
+}
+
+// valueSet holds the included and excluded values of one list parameter
+type valueSet struct {
+	Include []string
+	Exclude []string
+}
+
+// parseValueSet splits repeated and comma-separated values into lower-cased
+// include and exclude lists
+func parseValueSet(values []string) valueSet {
+	var set valueSet
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			v = strings.ToLower(strings.TrimSpace(v))
+			if strings.HasPrefix(v, "!") {
+				if v = strings.TrimSpace(v[1:]); v != "" {
+					set.Exclude = append(set.Exclude, v)
+				}
+			} else if v != "" {
+				set.Include = append(set.Include, v)
+			}
+		}
+	}
+	return set
+}
+
+// Empty reports whether the set places no constraint
+func (s valueSet) Empty() bool {
+	return len(s.Include) == 0 && len(s.Exclude) == 0
+}
+
+// matches applies the set using match to test a single lower-cased value:
+// at least one include (if any) must match and no exclude may
+func (s valueSet) matches(match func(v string) bool) bool {
+	for _, v := range s.Exclude {
+		if match(v) {
+			return false
+		}
+	}
+	if len(s.Include) == 0 {
+		return true
+	}
+	for _, v := range s.Include {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseItemFilter builds a filter from query parameters
+func parseItemFilter(params url.Values) (ItemFilter, error) {
+	f := ItemFilter{
+		Sources:     parseValueSet(params["source"]),
+		Categories:  parseValueSet(params["category"]),
+		Sentiments:  parseValueSet(params["sentiment"]),
+		Stocks:      parseValueSet(params["stock"]),
+		Keywords:    parseValueSet(params["keyword"]),
+		Nifty50Only: params.Get("nifty50") == "true",
+	}
+	if v := params.Get("min_priority"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return f, fmt.Errorf("min_priority must be an integer")
+		}
+		f.MinPriority = &n
+	}
+	if v := params.Get("min_sentiment"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return f, fmt.Errorf("min_sentiment must be a number")
+		}
+		f.MinSentiment = &n
+	}
+	return f, nil
+}
+
+// Match reports whether item passes every predicate. Priority must already
+// be computed for the item.
+func (f ItemFilter) Match(item NewsItem) bool {
+	if f.Nifty50Only && !item.HasNifty50 {
+		return false
+	}
+	if f.MinPriority != nil && item.Priority < *f.MinPriority {
+		return false
+	}
+	if f.MinSentiment != nil && item.SentimentScore < *f.MinSentiment {
+		return false
+	}
+	if !f.Sources.matches(equalFold(item.Source)) ||
+		!f.Categories.matches(equalFold(item.Category)) ||
+		!f.Sentiments.matches(equalFold(item.SentimentLabel)) {
+		return false
+	}
+
+	text := strings.ToLower(item.Title + " " + item.Description)
+	if !f.Stocks.Empty() {
+		// Nifty50Stock holds only the first mention, so check the text too
+		stock := strings.ToLower(item.Nifty50Stock)
+		if !f.Stocks.matches(func(v string) bool { return v == stock || strings.Contains(text, v) }) {
+			return false
+		}
+	}
+	if !f.Keywords.Empty() {
+		if !f.Keywords.matches(func(v string) bool {
+			for _, kw := range item.Keywords {
+				if strings.EqualFold(kw, v) {
+					return true
+				}
+			}
+			return strings.Contains(text, v)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalFold(field string) func(v string) bool {
+	return func(v string) bool { return strings.EqualFold(field, v) }
+}
+
+// Page size limits for the filter API
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// Sort orders accepted by the filter API; all sort descending with newer
+// items and then lower IDs breaking ties so pages are stable
+var itemOrders = map[string]func(a, b NewsItem) bool{
+	"priority": func(a, b NewsItem) bool {
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return newerFirst(a, b)
+	},
+	"pub_date": newerFirst,
+	"sentiment": func(a, b NewsItem) bool {
+		if a.SentimentScore != b.SentimentScore {
+			return a.SentimentScore > b.SentimentScore
+		}
+		return newerFirst(a, b)
+	},
+}
+
+func newerFirst(a, b NewsItem) bool {
+	if !a.PubDate.Equal(b.PubDate) {
+		return a.PubDate.After(b.PubDate)
+	}
+	return a.ID < b.ID
+}
+
+// PageRequest holds the pagination, ordering and time-range parameters.
+// AsOf is the instant priorities are computed at; it is carried in the
+// cursor so the recency bonus cannot shift items between pages.
+type PageRequest struct {
+	Limit  int
+	Sort   string
+	Since  time.Time
+	Until  time.Time
+	AsOf   time.Time
+	Cursor *pageCursor
+}
+
+// pageCursor records the sort key of the last item on the previous page
+type pageCursor struct {
+	Sort           string    `json:"s"`
+	ID             string    `json:"id"`
+	PubDate        time.Time `json:"t"`
+	AsOf           time.Time `json:"a"`
+	Priority       int       `json:"p,omitempty"`
+	SentimentScore float64   `json:"m,omitempty"`
+}
+
+// FilterResponse is the envelope returned by the filter API
+type FilterResponse struct {
+	Items      []NewsItem `json:"items"`
+	Total      int        `json:"total"`
+	Sort       string     `json:"sort"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// parsePageRequest reads limit, sort, since, until and cursor parameters
+func parsePageRequest(query url.Values) (PageRequest, error) {
+	req := PageRequest{Limit: defaultPageLimit, Sort: "pub_date", AsOf: time.Now()}
+
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return req, fmt.Errorf("limit must be a positive integer")
+		}
+		req.Limit = min(n, maxPageLimit)
+	}
+	if v := query.Get("sort"); v != "" {
+		if _, ok := itemOrders[v]; !ok {
+			return req, fmt.Errorf("sort must be one of priority, pub_date or sentiment")
+		}
+		req.Sort = v
+	}
+	var err error
+	if req.Since, req.Until, err = parseTimeRange(query); err != nil {
+		return req, err
+	}
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil || cursor.Sort != req.Sort || cursor.AsOf.IsZero() {
+			return req, fmt.Errorf("invalid cursor for sort %q", req.Sort)
+		}
+		req.Cursor = cursor
+		req.AsOf = cursor.AsOf
+	}
+	return req, nil
+}
+
+// parseTimeRange reads optional RFC3339 since and until bounds
+func parseTimeRange(query url.Values) (since, until time.Time, err error) {
+	for name, dst := range map[string]*time.Time{"since": &since, "until": &until} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return since, until, fmt.Errorf("%s must be an RFC3339 timestamp", name)
+			}
+			*dst = t
+		}
+	}
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return since, until, fmt.Errorf("since must be before until")
+	}
+	return since, until, nil
+}
+
+// paginate sorts items and returns the page after the request cursor
+func paginate(items []NewsItem, req PageRequest) FilterResponse {
+	less := itemOrders[req.Sort]
+	sort.Slice(items, func(i, j int) bool { return less(items[i], items[j]) })
+
+	start := 0
+	if req.Cursor != nil {
+		last := NewsItem{
+			ID:             req.Cursor.ID,
+			PubDate:        req.Cursor.PubDate,
+			Priority:       req.Cursor.Priority,
+			SentimentScore: req.Cursor.SentimentScore,
+		}
+		start = sort.Search(len(items), func(i int) bool { return less(last, items[i]) })
+	}
+
+	end := min(start+req.Limit, len(items))
+	resp := FilterResponse{
+		Items: items[start:end],
+		Total: len(items),
+		Sort:  req.Sort,
+	}
+	if end < len(items) {
+		last := items[end-1]
+		resp.NextCursor = encodeCursor(pageCursor{
+			Sort:           req.Sort,
+			ID:             last.ID,
+			PubDate:        last.PubDate,
+			AsOf:           req.AsOf,
+			Priority:       last.Priority,
+			SentimentScore: last.SentimentScore,
+		})
+	}
+	if resp.Items == nil {
+		resp.Items = []NewsItem{}
+	}
+	return resp
+}
+
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
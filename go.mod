@@ -2,6 +2,13 @@ module rss-aggregator
 
 go 1.21
 
-require github.com/gorilla/websocket v1.5.1
+require (
+	github.com/gorilla/websocket v1.5.1
+	go.etcd.io/bbolt v1.3.10
+	golang.org/x/net v0.17.0
+)
 
-require golang.org/x/net v0.17.0 // indirect
+require (
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
+)
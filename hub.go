@@ -0,0 +1,280 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Events a subscriber may fall behind by before the hub drops it
+const subscriberQueueSize = 16
+
+// Recent events kept for clients resuming after a reconnect
+const replayBufferSize = 128
+
+// Subscriber kinds, used for per-transport client counts
+const (
+	kindWebSocket = "websocket"
+	kindStream    = "stream"
+)
+
+// BroadcastEvent is one message published to live clients. Data is the JSON
+// payload, encoded once and shared by every transport; Payload is the value
+// it was encoded from, for clients that re-encode a filtered view.
+type BroadcastEvent struct {
+	ID      uint64
+	Type    string
+	Data    []byte
+	Payload interface{}
+}
+
+// Subscriber is one registered client. Events is closed by the hub when
+// the subscriber unsubscribes or is dropped for falling behind.
+type Subscriber struct {
+	Kind   string
+	Events chan BroadcastEvent
+}
+
+type subscription struct {
+	sub    *Subscriber
+	resume bool
+	since  uint64
+	reply  chan subscribeResult
+}
+
+type subscribeResult struct {
+	lastID  uint64
+	missed  []BroadcastEvent
+	resumed bool
+}
+
+// ResumeToken identifies the last event a client saw. Event IDs restart with
+// every process, so they are qualified by the hub's random epoch; a token
+// from another process never matches and the client gets a snapshot.
+type ResumeToken struct {
+	Epoch string
+	Seq   uint64
+}
+
+// String formats the token as "<epoch>.<seq>", as sent to clients
+func (t ResumeToken) String() string {
+	return t.Epoch + "." + strconv.FormatUint(t.Seq, 10)
+}
+
+// parseResumeToken parses "<epoch>.<seq>"
+func parseResumeToken(s string) (ResumeToken, error) {
+	epoch, seq, ok := strings.Cut(s, ".")
+	if !ok || epoch == "" {
+		return ResumeToken{}, fmt.Errorf("resume token must be <epoch>.<seq>")
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return ResumeToken{}, fmt.Errorf("resume token must be <epoch>.<seq>")
+	}
+	return ResumeToken{Epoch: epoch, Seq: n}, nil
+}
+
+// newEpoch returns a random identifier for this process's event stream
+func newEpoch() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Hub fans broadcast events out to subscribers. WebSocket and SSE clients
+// both receive updates through it. A single goroutine owns the registry, so
+// a slow client can only ever fill its own bounded queue.
+type Hub struct {
+	register   chan subscription
+	unregister chan *Subscriber
+	publish    chan BroadcastEvent
+	counts     chan chan map[string]int
+	done       chan struct{}
+	epoch      string
+	lastID     atomic.Uint64
+}
+
+var broadcastHub = newHub()
+
+// newHub starts a hub goroutine; Close stops it
+func newHub() *Hub {
+	h := &Hub{
+		register:   make(chan subscription),
+		unregister: make(chan *Subscriber),
+		publish:    make(chan BroadcastEvent),
+		counts:     make(chan chan map[string]int),
+		done:       make(chan struct{}),
+		epoch:      newEpoch(),
+	}
+	go h.run()
+	return h
+}
+
+func (h *Hub) run() {
+	subscribers := make(map[*Subscriber]struct{})
+	var history []BroadcastEvent // the last replayBufferSize events, oldest first
+	defer func() {
+		for sub := range subscribers {
+			close(sub.Events)
+		}
+	}()
+
+	for {
+		select {
+		case s := <-h.register:
+			subscribers[s.sub] = struct{}{}
+			result := subscribeResult{lastID: h.lastID.Load()}
+			if s.resume {
+				result.missed, result.resumed = replayFrom(history, s.since, result.lastID)
+			}
+			s.reply <- result
+
+		case sub := <-h.unregister:
+			if _, ok := subscribers[sub]; ok {
+				delete(subscribers, sub)
+				close(sub.Events)
+			}
+
+		case event := <-h.publish:
+			// Only this goroutine assigns IDs, so they increase in
+			// delivery order
+			event.ID = h.lastID.Add(1)
+			if len(history) == replayBufferSize {
+				copy(history, history[1:])
+				history = history[:len(history)-1]
+			}
+			history = append(history, event)
+			for sub := range subscribers {
+				select {
+				case sub.Events <- event:
+				default:
+					delete(subscribers, sub)
+					close(sub.Events)
+					log.Printf("🔌 Dropped slow %s client at event %d", sub.Kind, event.ID)
+				}
+			}
+
+		case reply := <-h.counts:
+			counts := make(map[string]int)
+			for sub := range subscribers {
+				counts[sub.Kind]++
+			}
+			reply <- counts
+
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// replayFrom returns the events after since. It reports false when some of
+// them have already left the buffer, or since is ahead of lastID. Tokens
+// from an earlier process are rejected by epoch before this is reached.
+func replayFrom(history []BroadcastEvent, since, lastID uint64) ([]BroadcastEvent, bool) {
+	if since > lastID {
+		return nil, false
+	}
+	if since == lastID {
+		return nil, true
+	}
+	if len(history) == 0 || history[0].ID > since+1 {
+		return nil, false
+	}
+	missed := history[since+1-history[0].ID:]
+	return append([]BroadcastEvent(nil), missed...), true
+}
+
+// Subscribe registers a new subscriber and returns it along with the ID of
+// the last event published before it joined
+func (h *Hub) Subscribe(kind string) (*Subscriber, uint64) {
+	sub, result := h.subscribe(kind, false, 0)
+	return sub, result.lastID
+}
+
+// Resume registers a subscriber that has seen every event up to token. It
+// returns the events published after it, or resumed=false when the token is
+// from another process or the events are no longer buffered, and the
+// client needs a snapshot instead. Registration and replay happen together,
+// so no event is missed or repeated.
+func (h *Hub) Resume(kind string, token ResumeToken) (sub *Subscriber, lastID uint64, missed []BroadcastEvent, resumed bool) {
+	sub, result := h.subscribe(kind, token.Epoch == h.epoch, token.Seq)
+	return sub, result.lastID, result.missed, result.resumed
+}
+
+func (h *Hub) subscribe(kind string, resume bool, since uint64) (*Subscriber, subscribeResult) {
+	s := subscription{
+		sub:    &Subscriber{Kind: kind, Events: make(chan BroadcastEvent, subscriberQueueSize)},
+		resume: resume,
+		since:  since,
+		reply:  make(chan subscribeResult, 1),
+	}
+	select {
+	case h.register <- s:
+		return s.sub, <-s.reply
+	case <-h.done:
+		close(s.sub.Events)
+		return s.sub, subscribeResult{lastID: h.lastID.Load()}
+	}
+}
+
+// Unsubscribe removes a subscriber and closes its queue. It is safe to call
+// after the hub has already dropped the subscriber.
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	select {
+	case h.unregister <- sub:
+	case <-h.done:
+	}
+}
+
+// Publish encodes v and queues it for every subscriber
+func (h *Hub) Publish(eventType string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case h.publish <- BroadcastEvent{Type: eventType, Data: data, Payload: v}:
+	case <-h.done:
+	}
+	return nil
+}
+
+// Epoch returns the hub's per-process stream identifier
+func (h *Hub) Epoch() string {
+	return h.epoch
+}
+
+// Token returns the resume token for event id
+func (h *Hub) Token(id uint64) ResumeToken {
+	return ResumeToken{Epoch: h.epoch, Seq: id}
+}
+
+// LastID returns the ID of the most recently published event
+func (h *Hub) LastID() uint64 {
+	return h.lastID.Load()
+}
+
+// Count returns the number of subscribers of the given kind
+func (h *Hub) Count(kind string) int {
+	reply := make(chan map[string]int, 1)
+	select {
+	case h.counts <- reply:
+		return (<-reply)[kind]
+	case <-h.done:
+		return 0
+	}
+}
+
+// Close stops the hub and closes every subscriber queue
+func (h *Hub) Close() {
+	close(h.done)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, sub *Subscriber) (BroadcastEvent, bool) {
+	t.Helper()
+	select {
+	case event, ok := <-sub.Events:
+		return event, ok
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return BroadcastEvent{}, false
+	}
+}
+
+func TestHubDeliversEventsInOrder(t *testing.T) {
+	h := newHub()
+	defer h.Close()
+
+	a, lastID := h.Subscribe(kindStream)
+	b, _ := h.Subscribe(kindWebSocket)
+	if lastID != 0 {
+		t.Fatalf("lastID = %d, want 0", lastID)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := h.Publish("update", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, sub := range []*Subscriber{a, b} {
+		for want := uint64(1); want <= 3; want++ {
+			event, ok := receive(t, sub)
+			if !ok {
+				t.Fatal("queue closed unexpectedly")
+			}
+			if event.ID != want || event.Type != "update" || string(event.Data) != strconv.FormatUint(want, 10) {
+				t.Fatalf("got event %d %q %s, want %d", event.ID, event.Type, event.Data, want)
+			}
+		}
+	}
+	if got := h.Count(kindWebSocket); got != 1 {
+		t.Fatalf("websocket count = %d, want 1", got)
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	h := newHub()
+	defer h.Close()
+
+	slow, _ := h.Subscribe(kindWebSocket)
+	fast, _ := h.Subscribe(kindWebSocket)
+
+	// The fast subscriber keeps up; the slow one never reads
+	for i := 0; i < subscriberQueueSize+5; i++ {
+		h.Publish("update", i)
+		if _, ok := receive(t, fast); !ok {
+			t.Fatalf("fast subscriber dropped at event %d", i)
+		}
+	}
+
+	// The slow subscriber gets its full queue and then a closed channel
+	for i := 0; i < subscriberQueueSize; i++ {
+		if _, ok := receive(t, slow); !ok {
+			t.Fatalf("queue closed after %d events, want %d", i, subscriberQueueSize)
+		}
+	}
+	if _, ok := receive(t, slow); ok {
+		t.Fatal("slow subscriber was not dropped")
+	}
+	if got := h.Count(kindWebSocket); got != 1 {
+		t.Fatalf("websocket count = %d, want 1", got)
+	}
+
+	// Unsubscribing a dropped subscriber is a no-op
+	h.Unsubscribe(slow)
+}
+
+func TestHubConcurrentUse(t *testing.T) {
+	h := newHub()
+	defer h.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			sub, _ := h.Subscribe(kindStream)
+			for j := 0; j < 5; j++ {
+				select {
+				case <-sub.Events:
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+			h.Unsubscribe(sub)
+			h.Unsubscribe(sub)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			h.Publish("update", i)
+			h.Count(kindStream)
+		}(i)
+	}
+	wg.Wait()
+
+	if got := h.LastID(); got != 20 {
+		t.Fatalf("LastID = %d, want 20", got)
+	}
+	if got := h.Count(kindStream); got != 0 {
+		t.Fatalf("stream count = %d, want 0", got)
+	}
+}
+
+func TestHubResumeReplaysMissedEvents(t *testing.T) {
+	h := newHub()
+	defer h.Close()
+
+	for i := 1; i <= 3; i++ {
+		h.Publish("delta", i)
+	}
+
+	sub, lastID, missed, resumed := h.Resume(kindWebSocket, h.Token(1))
+	if !resumed || lastID != 3 || len(missed) != 2 || missed[0].ID != 2 || missed[1].ID != 3 {
+		t.Fatalf("Resume(1) = last %d, missed %v, resumed %v", lastID, missed, resumed)
+	}
+	h.Unsubscribe(sub)
+
+	if _, _, missed, resumed := h.Resume(kindWebSocket, h.Token(3)); !resumed || len(missed) != 0 {
+		t.Fatalf("Resume(3) = missed %v, resumed %v; want nothing missed", missed, resumed)
+	}
+	if _, _, _, resumed := h.Resume(kindWebSocket, h.Token(4)); resumed {
+		t.Fatal("Resume from a future seq should need a snapshot")
+	}
+
+	// A token from another process never resumes, even when its seq is in range
+	other := newHub()
+	defer other.Close()
+	if _, _, missed, resumed := h.Resume(kindWebSocket, other.Token(1)); resumed || missed != nil {
+		t.Fatalf("Resume with another epoch = missed %v, resumed %v; want a snapshot", missed, resumed)
+	}
+
+	// Events that have left the buffer cannot be replayed
+	for i := 0; i < replayBufferSize; i++ {
+		h.Publish("delta", i)
+	}
+	if _, _, _, resumed := h.Resume(kindWebSocket, h.Token(2)); resumed {
+		t.Fatal("Resume from before the buffer should need a snapshot")
+	}
+	oldest := h.LastID() - replayBufferSize
+	if _, _, missed, resumed := h.Resume(kindWebSocket, h.Token(oldest)); !resumed || len(missed) != replayBufferSize {
+		t.Fatalf("Resume(%d) = %d missed, resumed %v; want the full buffer", oldest, len(missed), resumed)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// GUID is the RSS 2.0 <guid> element. IsPermaLink defaults to true when the
+// attribute is absent.
+type GUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink string `xml:"isPermaLink,attr"`
+}
+
+// Permalink reports whether the GUID is a URL pointing at the article
+func (g GUID) Permalink() bool {
+	if strings.EqualFold(strings.TrimSpace(g.IsPermaLink), "false") {
+		return false
+	}
+	value := strings.TrimSpace(g.Value)
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
+
+// Query parameters that only track the click and never change the article
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "yclid": true,
+	"mc_cid": true, "mc_eid": true, "igshid": true, "ref": true, "ref_src": true,
+	"cmpid": true, "icid": true, "_ga": true, "ito": true, "ftag": true,
+	"amp": true, "outputtype": true,
+}
+
+// canonicalURL normalizes an article link so the same story fetched through
+// different tracking links or AMP pages yields one URL. Unparseable links
+// are returned trimmed but otherwise unchanged.
+func canonicalURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Host = strings.TrimPrefix(u.Host, "amp.")
+	if u.Scheme == "http" {
+		u.Host = strings.TrimSuffix(u.Host, ":80")
+	}
+	if u.Scheme == "https" {
+		u.Host = strings.TrimSuffix(u.Host, ":443")
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	// AMP variants: /amp/..., .../amp, .../amp/ and ....amp.html
+	segments := strings.Split(u.Path, "/")
+	kept := segments[:0]
+	for _, segment := range segments {
+		if segment != "amp" {
+			kept = append(kept, segment)
+		}
+	}
+	path := strings.Join(kept, "/")
+	path = strings.Replace(path, ".amp.html", ".html", 1)
+	path = strings.TrimSuffix(path, ".amp")
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	u.Path = path
+	u.RawPath = ""
+
+	query := u.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "utm_") || trackingParams[lower] {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = encodeSortedQuery(query)
+
+	return u.String()
+}
+
+// encodeSortedQuery encodes a query with keys in sorted order so parameter
+// order does not change the canonical URL
+func encodeSortedQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		for _, value := range query[key] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(key))
+			b.WriteByte('=')
+			b.WriteString(url.QueryEscape(value))
+		}
+	}
+	return b.String()
+}
+
+// articleID derives a stable ID that survives re-fetches. A permalink GUID or
+// the canonical link identify the article across sources; an opaque GUID is
+// only unique within its feed, so it is scoped by source.
+func articleID(source string, guid GUID, link, title string) string {
+	var key string
+	switch {
+	case guid.Permalink():
+		key = "url\x00" + canonicalURL(guid.Value)
+	case strings.TrimSpace(guid.Value) != "":
+		key = "guid\x00" + source + "\x00" + strings.TrimSpace(guid.Value)
+	case link != "":
+		key = "url\x00" + canonicalURL(link)
+	default:
+		key = "title\x00" + source + "\x00" + strings.ToLower(strings.TrimSpace(title))
+	}
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
@@ -2,21 +2,19 @@ package main
 
 import (
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
 	"html/template"
 	"io"
 	"log"
 	"math"
 	"net/http"
+	"os"
 	"regexp"
 	"runtime"
 	"sort"
 	"strings"
 	"sync"
 	"time"
-
-	"github.com/gorilla/websocket"
 )
 
 // RSS feed structures
@@ -36,6 +34,7 @@ type Item struct {
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
 	Category    string `xml:"category"`
+	GUID        GUID   `xml:"guid"`
 	Source      string // We'll add this manually
 }
 
@@ -64,17 +63,6 @@ type SentimentData struct {
 	Overall  string  `json:"overall"`
 }
 
-// WebSocket upgrader
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for demo
-	},
-}
-
-// WebSocket clients
-var clients = make(map[*websocket.Conn]bool)
-var clientsMutex sync.RWMutex
-
 // NIFTY50 stocks list
 var nifty50Stocks = []string{
 	"RELIANCE", "TCS", "HDFCBANK", "INFY", "HINDUNILVR", "ICICIBANK", "ITC",
@@ -87,23 +75,26 @@ var nifty50Stocks = []string{
 }
 
 type NewsItem struct {
-	Title           string        `json:"title"`
-	Link            string        `json:"link"`
-	Description     string        `json:"description"`
-	PubDate         time.Time     `json:"pub_date"`
-	TimeAgo         string        `json:"time_ago"`
-	Category        string        `json:"category"`
-	Source          string        `json:"source"`
-	SourceColor     string        `json:"source_color"`
-	SourceName      string        `json:"source_name"`
-	HasNifty50      bool          `json:"has_nifty50"`
-	Nifty50Stock    string        `json:"nifty50_stock"`
-	SentimentScore  float64       `json:"sentiment_score"`
-	SentimentLabel  string        `json:"sentiment_label"`
-	Summary         string        `json:"summary"`
-	Keywords        []string      `json:"keywords"`
-	Priority        int           `json:"priority"`
-
+	ID             string            `json:"id"`
+	GUID           string            `json:"guid,omitempty"`
+	Title          string            `json:"title"`
+	Link           string            `json:"link"`
+	Description    string            `json:"description"`
+	PubDate        time.Time         `json:"pub_date"`
+	TimeAgo        string            `json:"time_ago"`
+	Category       string            `json:"category"`
+	Source         string            `json:"source"`
+	SourceColor    string            `json:"source_color"`
+	SourceName     string            `json:"source_name"`
+	HasNifty50     bool              `json:"has_nifty50"`
+	Nifty50Stock   string            `json:"nifty50_stock"`
+	SentimentScore float64           `json:"sentiment_score"`
+	SentimentLabel string            `json:"sentiment_label"`
+	Summary        string            `json:"summary"`
+	Keywords       []string          `json:"keywords"`
+	Priority       int               `json:"priority"`
+	ClusterID      string            `json:"cluster_id,omitempty"`
+	Alternates     []AlternateSource `json:"alternates,omitempty"`
 }
 
 type NewsData struct {
@@ -112,105 +103,146 @@ type NewsData struct {
 	TotalSources int            `json:"total_sources"`
 	Analytics    NewsAnalytics  `json:"analytics"`
 	Sentiment    SentimentData  `json:"sentiment"`
+	Clusters     []StoryCluster `json:"clusters"`
 }
 
-// RSS feed sources
-var rssSources = map[string]struct {
-	URL   string
-	Color string
-	Name  string
-}{
+// FeedSource describes one upstream feed. Format is one of the registered
+// feed parsers ("rss", "atom", "rdf", "json"); empty means auto-detect.
+type FeedSource struct {
+	URL             string
+	Color           string
+	Name            string
+	Format          string
+	Category        string
+	RefreshInterval time.Duration // 0 uses the configured refresh interval
+	RefreshJitter   time.Duration // 0 uses defaultJitterFraction of the interval
+	Headers         map[string]string
+	Enabled         bool
+}
+
+// RSS feed sources, built-in defaults used when no sources file exists.
+// Guarded by sourcesMutex once the server is running.
+var rssSources = map[string]FeedSource{
 	"TOI": {
-		URL:   "https://timesofindia.indiatimes.com/rssfeeds/1898055.cms",
-		Color: "#dc2626",
-		Name:  "Times of India Business",
+		URL:      "https://timesofindia.indiatimes.com/rssfeeds/1898055.cms",
+		Color:    "#dc2626",
+		Name:     "Times of India Business",
+		Category: "General News",
+		Enabled:  true,
 	},
 	"TH": {
-		URL:   "https://www.thehindu.com/business/markets/feeder/default.rss",
-		Color: "#dc2626",
-		Name:  "The Hindu Business",
+		URL:      "https://www.thehindu.com/business/markets/feeder/default.rss",
+		Color:    "#dc2626",
+		Name:     "The Hindu Business",
+		Category: "General News",
+		Enabled:  true,
 	},
 	"BL": {
-		URL:   "https://www.thehindubusinessline.com/markets/stock-markets/feeder/default.rss",
-		Color: "#16a34a",
-		Name:  "Business Line",
+		URL:      "https://www.thehindubusinessline.com/markets/stock-markets/feeder/default.rss",
+		Color:    "#16a34a",
+		Name:     "Business Line",
+		Category: "Markets",
+		Enabled:  true,
 	},
 	"LM": {
-		URL:   "https://www.livemint.com/rss/markets",
-		Color: "#0891b2",
-		Name:  "LiveMint Markets",
+		URL:      "https://www.livemint.com/rss/markets",
+		Color:    "#0891b2",
+		Name:     "LiveMint Markets",
+		Category: "Markets",
+		Enabled:  true,
 	},
 	"ZP": {
-		URL:   "https://pulse.zerodha.com/feed.php",
-		Color: "#7c3aed",
-		Name:  "Zerodha Pulse",
+		URL:             "https://pulse.zerodha.com/feed.php",
+		Color:           "#7c3aed",
+		Name:            "Zerodha Pulse",
+		Category:        "Markets",
+		RefreshInterval: time.Minute,
+		Enabled:         true,
 	},
 	"BS_MARKETS": {
-		URL:   "https://www.business-standard.com/rss/markets-106.rss",
-		Color: "#1e40af",
-		Name:  "Business Standard - Markets",
+		URL:      "https://www.business-standard.com/rss/markets-106.rss",
+		Color:    "#1e40af",
+		Name:     "Business Standard - Markets",
+		Category: "Business Standard",
+		Enabled:  true,
 	},
 	"BS_NEWS": {
-		URL:   "https://www.business-standard.com/rss/markets/news-10601.rss",
-		Color: "#1e40af",
-		Name:  "Business Standard - News",
+		URL:      "https://www.business-standard.com/rss/markets/news-10601.rss",
+		Color:    "#1e40af",
+		Name:     "Business Standard - News",
+		Category: "Business Standard",
+		Enabled:  true,
 	},
 	"BS_COMMODITIES": {
-		URL:   "https://www.business-standard.com/rss/markets/commodities-10608.rss",
-		Color: "#1e40af",
-		Name:  "Business Standard - Commodities",
+		URL:             "https://www.business-standard.com/rss/markets/commodities-10608.rss",
+		Color:           "#1e40af",
+		Name:            "Business Standard - Commodities",
+		Category:        "Business Standard",
+		RefreshInterval: time.Hour,
+		Enabled:         true,
 	},
 	"BS_IPO": {
-		URL:   "https://www.business-standard.com/rss/markets/ipo-10611.rss",
-		Color: "#1e40af",
-		Name:  "Business Standard - IPO",
+		URL:      "https://www.business-standard.com/rss/markets/ipo-10611.rss",
+		Color:    "#1e40af",
+		Name:     "Business Standard - IPO",
+		Category: "Business Standard",
+		Enabled:  true,
 	},
 	"BS_STOCK_MARKET": {
-		URL:   "https://www.business-standard.com/rss/markets/stock-market-news-10618.rss",
-		Color: "#1e40af",
-		Name:  "Business Standard - Stock Market",
+		URL:      "https://www.business-standard.com/rss/markets/stock-market-news-10618.rss",
+		Color:    "#1e40af",
+		Name:     "Business Standard - Stock Market",
+		Category: "Business Standard",
+		Enabled:  true,
 	},
 	"BS_CRYPTO": {
-		URL:   "https://www.business-standard.com/rss/markets/cryptocurrency-10622.rss",
-		Color: "#1e40af",
-		Name:  "Business Standard - Cryptocurrency",
+		URL:      "https://www.business-standard.com/rss/markets/cryptocurrency-10622.rss",
+		Color:    "#1e40af",
+		Name:     "Business Standard - Cryptocurrency",
+		Category: "Business Standard",
+		Enabled:  true,
 	},
 	"NSE_IT": {
-		URL:   "https://nsearchives.nseindia.com/content/RSS/Insider_Trading.xml",
-		Color: "#ea580c",
-		Name:  "NSE Insider Trading",
+		URL:             "https://nsearchives.nseindia.com/content/RSS/Insider_Trading.xml",
+		Color:           "#ea580c",
+		Name:            "NSE Insider Trading",
+		Category:        "Exchange Filings",
+		RefreshInterval: time.Minute,
+		Enabled:         true,
 	},
 	"NSE_BB": {
-		URL:   "https://nsearchives.nseindia.com/content/RSS/Daily_Buyback.xml",
-		Color: "#ea580c",
-		Name:  "NSE Daily Buy Back",
+		URL:      "https://nsearchives.nseindia.com/content/RSS/Daily_Buyback.xml",
+		Color:    "#ea580c",
+		Name:     "NSE Daily Buy Back",
+		Category: "Exchange Filings",
+		Enabled:  true,
 	},
 	"NSE_FR": {
-		URL:   "https://nsearchives.nseindia.com/content/RSS/Financial_Results.xml",
-		Color: "#ea580c",
-		Name:  "NSE Financial Results",
+		URL:      "https://nsearchives.nseindia.com/content/RSS/Financial_Results.xml",
+		Color:    "#ea580c",
+		Name:     "NSE Financial Results",
+		Category: "Exchange Filings",
+		Enabled:  true,
 	},
 	"NDTV_PROFIT": {
-		URL:   "https://feeds.feedburner.com/ndtvprofit-latest",
-		Color: "#1e40af",
-		Name:  "NDTV Profit",
+		URL:      "https://feeds.feedburner.com/ndtvprofit-latest",
+		Color:    "#1e40af",
+		Name:     "NDTV Profit",
+		Category: "General News",
+		Enabled:  true,
 	},
 }
 
 // Real-time data structures (no historical storage)
 var (
-	currentNews   []NewsItem    // Only current batch, cleared on each refresh
+	currentNews   []NewsItem                    // Merged view of sourceItems, rebuilt after each fetch
+	sourceItems   = make(map[string][]NewsItem) // Latest items per source key
 	lastFetchTime time.Time
 	newsMutex     sync.RWMutex
-	liveAnalytics NewsAnalytics // Real-time analytics only
-	liveSentiment SentimentData // Real-time sentiment only
-)
-
-// Configuration for memory efficiency
-const (
-	MAX_ARTICLES_PER_SOURCE = 10  // Limit articles per source
-	MAX_TOTAL_ARTICLES      = 150 // Total articles limit (15 sources × 10)
-	MEMORY_CLEANUP_INTERVAL = 1   // Cleanup every 1 minute
+	rebuildMutex  sync.Mutex
+	liveAnalytics NewsAnalytics  // Real-time analytics only
+	liveSentiment SentimentData  // Real-time sentiment only
+	liveClusters  []StoryCluster // Near-duplicate story groups in currentNews
 )
 
 // Advanced AI-powered features
@@ -291,7 +323,8 @@ func calculateReadingTime(text string) int {
 	return int(math.Ceil(float64(words) / 200.0))
 }
 
-func calculatePriority(item NewsItem) int {
+// calculatePriority scores an item; the recency bonus is measured at now
+func calculatePriority(item NewsItem, now time.Time) int {
 	priority := 0
 	
 	// Higher priority for NIFTY50 mentions
@@ -307,7 +340,7 @@ func calculatePriority(item NewsItem) int {
 	}
 	
 	// Higher priority for recent news
-	hoursSincePublication := time.Since(item.PubDate).Hours()
+	hoursSincePublication := now.Sub(item.PubDate).Hours()
 	if hoursSincePublication < 1 {
 		priority += 25
 	} else if hoursSincePublication < 6 {
@@ -460,70 +493,49 @@ func min(a, b int) int {
 	return b
 }
 
-// WebSocket handlers
-func handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
-		return
-	}
-	defer conn.Close()
-	
-	clientsMutex.Lock()
-	clients[conn] = true
-	clientsMutex.Unlock()
-	
-	log.Printf("Client connected. Total clients: %d", len(clients))
-	
-	// Send initial real-time data
+// currentNewsData returns the real-time payload sent to live clients
+func currentNewsData() NewsData {
 	newsMutex.RLock()
-	data := NewsData{
+	defer newsMutex.RUnlock()
+
+	return NewsData{
 		Items:        currentNews,
 		LastUpdated:  lastFetchTime.In(istLocation).Format("Jan 2, 2006 at 3:04 PM"),
-		TotalSources: len(rssSources),
+		TotalSources: enabledSourceCount(),
 		Analytics:    liveAnalytics,
 		Sentiment:    liveSentiment,
-	}
-	newsMutex.RUnlock()
-	
-	conn.WriteJSON(data)
-	
-	// Keep connection alive and handle disconnection
-	for {
-		_, _, err := conn.ReadMessage()
-		if err != nil {
-			clientsMutex.Lock()
-			delete(clients, conn)
-			clientsMutex.Unlock()
-			log.Printf("Client disconnected. Total clients: %d", len(clients))
-			break
-		}
+		Clusters:     liveClusters,
 	}
 }
 
+// broadcastUpdate publishes what changed since the previous broadcast to
+// WebSocket and SSE clients; they receive a full snapshot only on connect
 func broadcastUpdate() {
-	newsMutex.RLock()
-	data := NewsData{
-		Items:        currentNews,
-		LastUpdated:  lastFetchTime.In(istLocation).Format("Jan 2, 2006 at 3:04 PM"),
-		TotalSources: len(rssSources),
-		Analytics:    liveAnalytics,
-		Sentiment:    liveSentiment,
+	if err := broadcastHub.Publish("delta", nextDelta(currentNewsData())); err != nil {
+		log.Printf("❌ Error encoding update: %v", err)
 	}
-	newsMutex.RUnlock()
-	
-	clientsMutex.RLock()
-	for client := range clients {
-		err := client.WriteJSON(data)
-		if err != nil {
-			client.Close()
-			delete(clients, client)
-		}
-	}
-	clientsMutex.RUnlock()
 }
 
-func fetchRSSFeed(url string) (*RSS, error) {
+// Conditional GET validators and the last parsed feed, per rssSources key
+type feedCacheEntry struct {
+	URL          string
+	ETag         string
+	LastModified string
+	Feed         *Feed
+}
+
+var (
+	feedCache      = make(map[string]feedCacheEntry)
+	feedCacheMutex sync.Mutex
+)
+
+func fetchRSSFeed(key string, src FeedSource) (feed *Feed, err error) {
+	// Every call is recorded for /api/sources/health
+	rec := FetchRecord{Time: time.Now()}
+	defer func() {
+		recordFetch(key, &rec, feed, err)
+	}()
+
 	client := &http.Client{
 		Timeout: 15 * time.Second,
 		Transport: &http.Transport{
@@ -533,14 +545,32 @@ func fetchRSSFeed(url string) (*RSS, error) {
 		},
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequest("GET", src.URL, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	// Add headers to mimic a real browser
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Accept", "application/rss+xml, application/xml, text/xml")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/rdf+xml, application/feed+json, application/xml, text/xml")
+	for name, value := range src.Headers {
+		req.Header.Set(name, value)
+	}
+
+	// Revalidate against the last successful fetch of the same URL
+	feedCacheMutex.Lock()
+	cached, hasCache := feedCache[key]
+	feedCacheMutex.Unlock()
+	if hasCache && cached.URL == src.URL {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	} else {
+		hasCache = false
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -548,18 +578,39 @@ func fetchRSSFeed(url string) (*RSS, error) {
 	}
 	defer resp.Body.Close()
 
+	rec.Status = resp.StatusCode
+
+	if resp.StatusCode == http.StatusNotModified && hasCache {
+		rec.NotModified = true
+		log.Printf("♻️  %s not modified, reusing %d cached items", key, len(cached.Feed.Items))
+		return cached.Feed, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+
 	body, err := io.ReadAll(resp.Body)
+	rec.Bytes = int64(len(body))
 	if err != nil {
 		return nil, err
 	}
 
-	var rss RSS
-	err = xml.Unmarshal(body, &rss)
+	feed, err = parseFeed(body, resp.Header.Get("Content-Type"), src.Format)
 	if err != nil {
+		rec.ParseError = true
 		return nil, err
 	}
 
-	return &rss, nil
+	feedCacheMutex.Lock()
+	feedCache[key] = feedCacheEntry{
+		URL:          src.URL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Feed:         feed,
+	}
+	feedCacheMutex.Unlock()
+
+	return feed, nil
 }
 
 // Load IST location
@@ -581,6 +632,7 @@ func parseTime(dateStr string) time.Time {
 		"Mon, 02 Jan 2006 15:04:05 -0700",
 		"2006-01-02T15:04:05Z",
 		"2006-01-02T15:04:05-07:00",
+		time.RFC3339, // Atom dates, including fractional seconds
 		"Mon, 2 Jan 2006 15:04:05 MST",
 		"Mon, 2 Jan 2006 15:04:05 -0700",
 		"Mon, 02 Jan 2006 15:04:05 GMT",
@@ -689,123 +741,200 @@ func checkForNifty50(text string) (bool, string) {
 	return false, ""
 }
 
+// fetchAllNews fetches every enabled source concurrently and publishes the
+// result once. Used for the initial load; afterwards the scheduler refreshes
+// each source on its own interval.
 func fetchAllNews() {
 	log.Println("🔄 Fetching real-time news (memory optimized)...")
-	
-	// Clear previous data for real-time operation
-	newsMutex.Lock()
-	currentNews = nil // Clear all previous news
-	newsMutex.Unlock()
-	
-	var allNews []NewsItem
-	var wg sync.WaitGroup
-	var mu sync.Mutex
 
-	for sourceName, source := range rssSources {
+	var wg sync.WaitGroup
+	for sourceName, source := range snapshotSources() {
+		if !source.Enabled {
+			continue
+		}
 		wg.Add(1)
-		go func(sName string, src struct {
-			URL   string
-			Color string
-			Name  string
-		}) {
+		go func(sName string, src FeedSource) {
 			defer wg.Done()
+			fetchSource(sName, src)
+		}(sourceName, source)
+	}
+	wg.Wait()
 
-			rss, err := fetchRSSFeed(src.URL)
-			if err != nil {
-				log.Printf("❌ Error fetching %s (%s): %v", sName, src.Name, err)
-				return
-			}
+	rebuildNews()
 
-			// Limit articles per source for memory efficiency
-			itemsToProcess := len(rss.Channel.Items)
-			if itemsToProcess > MAX_ARTICLES_PER_SOURCE {
-				itemsToProcess = MAX_ARTICLES_PER_SOURCE
-				log.Printf("⚡ Limited %s to %d items (memory optimization)", sName, MAX_ARTICLES_PER_SOURCE)
-			}
+	newsMutex.Lock()
+	firstFetchDone = true
+	newsMutex.Unlock()
 
-			log.Printf("✅ Fetched %s: processing %d/%d items", sName, itemsToProcess, len(rss.Channel.Items))
+	// Force garbage collection for memory efficiency
+	runtime.GC()
+}
 
-			mu.Lock()
-			for i := 0; i < itemsToProcess; i++ {
-				item := rss.Channel.Items[i]
-				
-				if item.Title == "" {
-					continue // Skip empty items
-				}
+// fetchSource fetches one source and replaces its items in the shared set.
+// On error the previous items for the source are kept until they age out.
+func fetchSource(sName string, src FeedSource) bool {
+	feed, err := fetchRSSFeed(sName, src)
+	if err != nil {
+		log.Printf("❌ Error fetching %s (%s): %v", sName, src.Name, err)
+		recordFetchFailure(sName, src, err)
+		return false
+	}
+	recordFetchSuccess(sName)
 
-				pubTime := parseTime(item.PubDate)
-				
-				// Skip articles older than 24 hours for real-time focus
-				if time.Since(pubTime) > 24*time.Hour {
-					continue
-				}
+	items := processFeedItems(sName, src, feed)
+	recordLiveItems(sName, items)
 
-				// Check for NIFTY50 mentions in title and description
-				hasNifty50Title, niftyStock := checkForNifty50(item.Title)
-				hasNifty50Desc, niftyStockDesc := checkForNifty50(item.Description)
-				hasNifty50 := hasNifty50Title || hasNifty50Desc
-				niftyStockName := niftyStock
-				if niftyStock == "" && niftyStockDesc != "" {
-					niftyStockName = niftyStockDesc
-				}
+	// The source may have been removed by a reload while this fetch ran
+	if current, ok := lookupSource(sName); !ok || !current.Enabled {
+		return false
+	}
 
-				// Lightweight processing for memory efficiency
-				fullText := item.Title + " " + item.Description
-				sentimentScore, sentimentLabel := analyzeSentiment(fullText)
-				keywords := extractKeywords(fullText)
-				summary := generateSummary(item.Title, item.Description)
-
-
-				newsItem := NewsItem{
-					Title:          item.Title,
-					Link:           item.Link,
-					Description:    cleanDescription(item.Description),
-					PubDate:        pubTime,
-					TimeAgo:        timeAgo(pubTime),
-					Category:       item.Category,
-					Source:         sName,
-					SourceColor:    src.Color,
-					SourceName:     src.Name,
-					HasNifty50:     hasNifty50,
-					Nifty50Stock:   niftyStockName,
-					SentimentScore: sentimentScore,
-					SentimentLabel: sentimentLabel,
-					Summary:        summary,
-					Keywords:       keywords,
-				}
+	if err := articleStore.Upsert(items); err != nil {
+		log.Printf("❌ Error storing %s articles: %v", sName, err)
+	}
+	searchIndex.Add(items)
 
-				// Calculate priority
-				newsItem.Priority = calculatePriority(newsItem)
+	newsMutex.Lock()
+	sourceItems[sName] = items
+	newsMutex.Unlock()
+	return true
+}
 
-				allNews = append(allNews, newsItem)
-				
-				// Memory safety check
-				if len(allNews) >= MAX_TOTAL_ARTICLES {
-					log.Printf("⚠️  Reached max articles limit (%d), stopping collection", MAX_TOTAL_ARTICLES)
-					break
-				}
-			}
-			mu.Unlock()
-		}(sourceName, source)
+// processFeedItems turns parsed feed items into NewsItems for one source
+func processFeedItems(sName string, src FeedSource, feed *Feed) []NewsItem {
+	// Limit articles per source for memory efficiency
+	itemsToProcess := len(feed.Items)
+	if itemsToProcess > appConfig.MaxArticlesPerSource {
+		itemsToProcess = appConfig.MaxArticlesPerSource
+		log.Printf("⚡ Limited %s to %d items (memory optimization)", sName, appConfig.MaxArticlesPerSource)
 	}
 
-	wg.Wait()
+	log.Printf("✅ Fetched %s: processing %d/%d items", sName, itemsToProcess, len(feed.Items))
 
-	// Limit total articles and sort by priority + recency
-	if len(allNews) > MAX_TOTAL_ARTICLES {
-		log.Printf("⚡ Trimming to %d articles for memory efficiency", MAX_TOTAL_ARTICLES)
-		
-		// Sort by priority first, then by publication date (newest first)
-		sort.Slice(allNews, func(i, j int) bool {
-			if allNews[i].Priority == allNews[j].Priority {
-				return allNews[i].PubDate.After(allNews[j].PubDate)
+	var news []NewsItem
+	for i := 0; i < itemsToProcess; i++ {
+		item := feed.Items[i]
+
+		if item.Title == "" {
+			continue // Skip empty items
+		}
+
+		pubTime := parseTime(item.PubDate)
+
+		// Older articles are still stored for history; rebuildNews keeps
+		// only the last 24 hours in the real-time view
+		if time.Since(pubTime) > time.Duration(appConfig.Retention) {
+			continue
+		}
+
+		// Check for NIFTY50 mentions in title and description
+		hasNifty50Title, niftyStock := checkForNifty50(item.Title)
+		hasNifty50Desc, niftyStockDesc := checkForNifty50(item.Description)
+		hasNifty50 := hasNifty50Title || hasNifty50Desc
+		niftyStockName := niftyStock
+		if niftyStock == "" && niftyStockDesc != "" {
+			niftyStockName = niftyStockDesc
+		}
+
+		// Lightweight processing for memory efficiency
+		fullText := item.Title + " " + item.Description
+		sentimentScore, sentimentLabel := analyzeSentiment(fullText)
+		keywords := extractKeywords(fullText)
+		summary := generateSummary(item.Title, item.Description)
+
+		// A permalink GUID stands in for a missing link
+		link := item.Link
+		if link == "" && item.GUID.Permalink() {
+			link = item.GUID.Value
+		}
+
+		newsItem := NewsItem{
+			GUID:           strings.TrimSpace(item.GUID.Value),
+			Title:          item.Title,
+			Link:           canonicalURL(link),
+			Description:    cleanDescription(item.Description),
+			PubDate:        pubTime,
+			TimeAgo:        timeAgo(pubTime),
+			Category:       item.Category,
+			Source:         sName,
+			SourceColor:    src.Color,
+			SourceName:     src.Name,
+			HasNifty50:     hasNifty50,
+			Nifty50Stock:   niftyStockName,
+			SentimentScore: sentimentScore,
+			SentimentLabel: sentimentLabel,
+			Summary:        summary,
+			Keywords:       keywords,
+		}
+
+		newsItem.ID = articleID(sName, item.GUID, newsItem.Link, item.Title)
+
+		// Calculate priority
+		newsItem.Priority = calculatePriority(newsItem, time.Now())
+
+		news = append(news, newsItem)
+	}
+	return news
+}
+
+// dropSourceItems removes the items of sources that were deleted or disabled
+func dropSourceItems(keys []string) {
+	newsMutex.Lock()
+	for _, key := range keys {
+		delete(sourceItems, key)
+	}
+	newsMutex.Unlock()
+}
+
+// rebuildNews merges the per-source items into currentNews, refreshes the
+// analytics and broadcasts the result
+func rebuildNews() {
+	rebuildMutex.Lock()
+	defer rebuildMutex.Unlock()
+
+	var allNews []NewsItem
+	newsMutex.RLock()
+	for _, items := range sourceItems {
+		for _, item := range items {
+			// Items kept from earlier fetches age out here
+			if time.Since(item.PubDate) > 24*time.Hour {
+				continue
 			}
-			return allNews[i].Priority > allNews[j].Priority
-		})
-		
-		// Keep only top articles
-		allNews = allNews[:MAX_TOTAL_ARTICLES]
+			// Recency bonus changes as items age
+			item.Priority = calculatePriority(item, time.Now())
+			allNews = append(allNews, item)
+		}
+	}
+	newsMutex.RUnlock()
+
+	// Sort by priority first, then by publication date (newest first)
+	sort.Slice(allNews, func(i, j int) bool {
+		if allNews[i].Priority == allNews[j].Priority {
+			return allNews[i].PubDate.After(allNews[j].PubDate)
+		}
+		return allNews[i].Priority > allNews[j].Priority
+	})
+
+	// Overlapping feeds can carry the same article; keep the highest ranked copy
+	seen := make(map[string]bool, len(allNews))
+	unique := allNews[:0]
+	for _, item := range allNews {
+		if seen[item.ID] {
+			continue
+		}
+		seen[item.ID] = true
+		unique = append(unique, item)
 	}
+	allNews = unique
+
+	// Limit total articles, keeping the top ones
+	if len(allNews) > appConfig.MaxTotalArticles {
+		log.Printf("⚡ Trimming to %d articles for memory efficiency", appConfig.MaxTotalArticles)
+		allNews = allNews[:appConfig.MaxTotalArticles]
+	}
+
+	// Group the same story reported by different outlets
+	clusters := clusterItems(allNews)
 
 	// Generate real-time analytics (no historical data)
 	analyticsData := generateAnalytics(allNews)
@@ -817,17 +946,15 @@ func fetchAllNews() {
 	lastFetchTime = time.Now()
 	liveAnalytics = analyticsData
 	liveSentiment = sentimentData
+	liveClusters = clusters
 	newsMutex.Unlock()
 
-	log.Printf("📊 Real-time articles: %d (max: %d)", len(allNews), MAX_TOTAL_ARTICLES)
+	log.Printf("📊 Real-time articles: %d (max: %d)", len(allNews), appConfig.MaxTotalArticles)
 	if len(analyticsData.TopKeywords) > 0 {
 		log.Printf("🎯 Top keyword: %s", analyticsData.TopKeywords[0].Keyword)
 	}
 	log.Printf("😊 Live sentiment: %s", sentimentData.Overall)
 
-	// Force garbage collection for memory efficiency
-	runtime.GC()
-
 	// Broadcast real-time update to WebSocket clients
 	broadcastUpdate()
 }
@@ -836,14 +963,17 @@ func getCurrentNews() ([]NewsItem, string) {
 	newsMutex.RLock()
 	defer newsMutex.RUnlock()
 
-	// Update time ago for all items (real-time)
-	for i := range currentNews {
-		currentNews[i].TimeAgo = timeAgo(currentNews[i].PubDate)
+	// Update time ago for all items (real-time) on a copy, since other
+	// readers share currentNews under the read lock
+	news := make([]NewsItem, len(currentNews))
+	copy(news, currentNews)
+	for i := range news {
+		news[i].TimeAgo = timeAgo(news[i].PubDate)
 	}
 
 	// Format the time in IST
 	istTime := lastFetchTime.In(istLocation)
-	return currentNews, istTime.Format("Jan 2, 2006 at 3:04 PM")
+	return news, istTime.Format("Jan 2, 2006 at 3:04 PM")
 }
 
 // Real-time API handlers (no historical data)
@@ -869,35 +999,46 @@ func sentimentHandler(w http.ResponseWriter, r *http.Request) {
 
 func filterHandler(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
-	source := query.Get("source")
-	category := query.Get("category")
-	sentiment := query.Get("sentiment")
-	nifty50Only := query.Get("nifty50") == "true"
-	
-	newsMutex.RLock()
-	allItems := currentNews
-	newsMutex.RUnlock()
+	clustered := query.Get("clustered") == "true"
+
+	filter, err := parseItemFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := parsePageRequest(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	
+	// Served from the article store so results include history within the
+	// retention window, not just the current real-time batch
+	allItems, err := articleStore.Range(page.Since, page.Until)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	var filtered []NewsItem
 	for _, item := range allItems {
-		if source != "" && item.Source != source {
-			continue
-		}
-		if category != "" && item.Category != category {
-			continue
-		}
-		if sentiment != "" && item.SentimentLabel != sentiment {
-			continue
-		}
-		if nifty50Only && !item.HasNifty50 {
-			continue
+		item.TimeAgo = timeAgo(item.PubDate)
+		item.Priority = calculatePriority(item, page.AsOf)
+		if filter.Match(item) {
+			filtered = append(filtered, item)
 		}
-		filtered = append(filtered, item)
 	}
-	
+
+	// Stored items are clustered per request since membership depends on
+	// which items matched; clustered=true returns one item per story
+	clusters := clusterItems(filtered)
+	if clustered {
+		filtered = collapseClusters(filtered, clusters)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	json.NewEncoder(w).Encode(filtered)
+	json.NewEncoder(w).Encode(paginate(filtered, page))
 }
 
 func homeHandler(w http.ResponseWriter, r *http.Request) {
@@ -906,8 +1047,12 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 	newsMutex.RLock()
 	analyticsData := liveAnalytics
 	sentimentData := liveSentiment
+	clusters := liveClusters
 	newsMutex.RUnlock()
 
+	// One card per story; alternates are listed on the primary card
+	news = collapseClusters(news, clusters)
+
 	// Filter news based on source if specified
 	source := r.URL.Query().Get("source")
 	if source != "" {
@@ -940,7 +1085,7 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Load and parse template
-	tmpl, err := template.ParseFiles("template.html")
+	tmpl, err := template.ParseFiles(appConfig.TemplatePath)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -949,9 +1094,10 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 	data := NewsData{
 		Items:        news,
 		LastUpdated:  lastUpdated,
-		TotalSources: len(rssSources),
+		TotalSources: enabledSourceCount(),
 		Analytics:    analyticsData,
 		Sentiment:    sentimentData,
+		Clusters:     clusters,
 	}
 
 	w.Header().Set("Content-Type", "text/html")
@@ -966,6 +1112,13 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
+    // Resolve configuration from defaults, config file, environment and flags
+    cfg, err := loadConfig(os.Args[1:])
+    if err != nil {
+        log.Fatalf("Invalid configuration: %v", err)
+    }
+    applyConfig(cfg)
+
     // Initialize HTTP routes
     http.HandleFunc("/", homeHandler)
     http.HandleFunc("/filter", filterHandler)
@@ -973,17 +1126,41 @@ func main() {
     http.HandleFunc("/analytics", analyticsHandler)
     http.HandleFunc("/sentiment", sentimentHandler)
 
-    // Start background news fetching
+    // API namespace; the unprefixed routes above are kept for existing clients
+    http.HandleFunc("/api/status", statusHandler)
+    http.HandleFunc("/api/analytics", analyticsHandler)
+    http.HandleFunc("/api/sentiment", sentimentHandler)
+    http.HandleFunc("/api/filter", filterHandler)
+    http.HandleFunc("/api/search", searchHandler)
+    http.HandleFunc("/feed.rss", rssFeedHandler)
+    http.HandleFunc("/feed.atom", atomFeedHandler)
+    http.HandleFunc("/feed.json", jsonFeedHandler)
+    http.HandleFunc("/api/export/", exportHandler)
+    http.HandleFunc("/api/stream", streamHandler)
+    http.HandleFunc("/api/sources", sourcesStatusHandler)
+    http.HandleFunc("/api/sources/health", sourcesHealthHandler)
+    http.HandleFunc("/api/sources.opml", sourcesOPMLHandler)
+    http.HandleFunc("/api/", apiNotFoundHandler)
+
+    // Open the article store and prune it on the retention schedule
+    articleStore = openArticleStore(appConfig.StorePath)
+    loadSearchIndex()
+    go retentionLoop()
+
+    // Load feed sources and watch the file for changes
+    initSources(appConfig.SourcesFile)
+    go watchSources()
+    go memoryCleanupLoop()
+
+    // Initial load of every source, then per-source refresh schedules
     go func() {
-        for {
-            fetchAllNews()
-            time.Sleep(5 * time.Minute)
-        }
+        fetchAllNews()
+        runScheduler()
     }()
 
     // Start the server
-    fmt.Println("Server starting on :8080...")
-    if err := http.ListenAndServe(":8080", nil); err != nil {
+    fmt.Printf("Server starting on %s...\n", appConfig.ListenAddr)
+    if err := http.ListenAndServe(appConfig.ListenAddr, nil); err != nil {
         log.Fatal(err)
     }
 }
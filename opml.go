@@ -0,0 +1,290 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// OPML 2.0 document structures
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    OPMLHead `xml:"head"`
+	Body    OPMLBody `xml:"body"`
+}
+
+type OPMLHead struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type OPMLBody struct {
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+type OPMLOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Category string        `xml:"category,attr,omitempty"`
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+// opmlFeed is a feed outline flattened out of its folder hierarchy
+type opmlFeed struct {
+	Title    string
+	URL      string
+	Category string
+}
+
+// parseOPML reads an OPML document and returns every outline with an xmlUrl.
+// The category is the enclosing folder, or the outline's own category
+// attribute when it sits at the top level.
+func parseOPML(r io.Reader) ([]opmlFeed, error) {
+	var doc OPML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parsing OPML: %v", err)
+	}
+
+	var feeds []opmlFeed
+	var walk func(outlines []OPMLOutline, folder string)
+	walk = func(outlines []OPMLOutline, folder string) {
+		for _, outline := range outlines {
+			title := outline.Title
+			if title == "" {
+				title = outline.Text
+			}
+
+			if outline.XMLURL == "" {
+				walk(outline.Outlines, title)
+				continue
+			}
+
+			category := folder
+			if category == "" {
+				category = opmlCategory(outline.Category)
+			}
+			feeds = append(feeds, opmlFeed{
+				Title:    strings.TrimSpace(title),
+				URL:      strings.TrimSpace(outline.XMLURL),
+				Category: category,
+			})
+		}
+	}
+	walk(doc.Body.Outlines, "")
+
+	return feeds, nil
+}
+
+// opmlCategory takes the first path of an OPML category attribute such as
+// "/Markets/Commodities,/Tags" and returns its last segment
+func opmlCategory(attr string) string {
+	first := strings.Split(attr, ",")[0]
+	segments := strings.Split(strings.Trim(first, "/ "), "/")
+	return strings.TrimSpace(segments[len(segments)-1])
+}
+
+// mergeOPMLFeeds adds feeds to a copy of sources. Feeds whose URL is already
+// registered keep their key and settings; a missing category is filled in.
+func mergeOPMLFeeds(sources map[string]FeedSource, feeds []opmlFeed) (map[string]FeedSource, int, int) {
+	merged := make(map[string]FeedSource, len(sources)+len(feeds))
+	byURL := make(map[string]string, len(sources))
+	for key, src := range sources {
+		merged[key] = src
+		byURL[src.URL] = key
+	}
+
+	added, updated := 0, 0
+	for _, feed := range feeds {
+		if key, ok := byURL[feed.URL]; ok {
+			src := merged[key]
+			if src.Category == "" && feed.Category != "" {
+				src.Category = feed.Category
+				merged[key] = src
+				updated++
+			}
+			continue
+		}
+
+		entry := SourceConfig{
+			Key:      uniqueSourceKey(merged, feed.Title),
+			URL:      feed.URL,
+			Name:     feed.Title,
+			Category: feed.Category,
+		}
+		src, err := entry.toFeedSource()
+		if err != nil {
+			log.Printf("⚠️  Skipping OPML outline %q: %v", feed.Title, err)
+			continue
+		}
+		merged[entry.Key] = src
+		byURL[feed.URL] = entry.Key
+		added++
+	}
+
+	return merged, added, updated
+}
+
+// uniqueSourceKey derives an upper-case key like "NDTV_PROFIT" from a title
+func uniqueSourceKey(sources map[string]FeedSource, title string) string {
+	base := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, title)
+	base = strings.Trim(base, "_")
+	for strings.Contains(base, "__") {
+		base = strings.ReplaceAll(base, "__", "_")
+	}
+	if base == "" {
+		base = "FEED"
+	}
+
+	key := base
+	for i := 2; ; i++ {
+		if _, exists := sources[key]; !exists {
+			return key
+		}
+		key = fmt.Sprintf("%s_%d", base, i)
+	}
+}
+
+// buildOPML renders the source registry with categories as folders
+func buildOPML(sources map[string]FeedSource) OPML {
+	keys := make([]string, 0, len(sources))
+	for key := range sources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	doc := OPML{
+		Version: "2.0",
+		Head: OPMLHead{
+			Title:       "Business News Aggregator sources",
+			DateCreated: time.Now().In(istLocation).Format(time.RFC1123Z),
+		},
+	}
+
+	folders := make(map[string]int)
+	for _, key := range keys {
+		src := sources[key]
+		outline := OPMLOutline{
+			Text:   src.Name,
+			Title:  src.Name,
+			Type:   "rss",
+			XMLURL: src.URL,
+		}
+
+		if src.Category == "" {
+			doc.Body.Outlines = append(doc.Body.Outlines, outline)
+			continue
+		}
+
+		idx, ok := folders[src.Category]
+		if !ok {
+			doc.Body.Outlines = append(doc.Body.Outlines, OPMLOutline{
+				Text:  src.Category,
+				Title: src.Category,
+			})
+			idx = len(doc.Body.Outlines) - 1
+			folders[src.Category] = idx
+		}
+		doc.Body.Outlines[idx].Outlines = append(doc.Body.Outlines[idx].Outlines, outline)
+	}
+
+	return doc
+}
+
+// Content types accepted for OPML import. None is CORS-safelisted, so a
+// cross-site page cannot post one without a preflight, which is refused.
+var opmlImportTypes = map[string]bool{
+	"text/x-opml":     true,
+	"application/xml": true,
+	"text/xml":        true,
+}
+
+// authorizeOPMLImport reports whether r may import sources. Import changes
+// what the server fetches and rewrites the sources file, so it requires
+// the configured bearer token and is disabled without one.
+func authorizeOPMLImport(w http.ResponseWriter, r *http.Request) bool {
+	if appConfig.OPMLImportToken == "" {
+		http.Error(w, "OPML import is disabled; set OPML_IMPORT_TOKEN to enable it", http.StatusForbidden)
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(appConfig.OPMLImportToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="opml-import"`)
+		http.Error(w, "missing or invalid import token", http.StatusUnauthorized)
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || !opmlImportTypes[mediaType] {
+		http.Error(w, "Content-Type must be text/x-opml or application/xml", http.StatusUnsupportedMediaType)
+		return false
+	}
+	return true
+}
+
+// sourcesOPMLHandler exports the sources as OPML on GET, open to any
+// origin. POST merges an uploaded OPML document into them; it needs the
+// import token and is same-origin only.
+func sourcesOPMLHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		doc := buildOPML(snapshotSources())
+
+		w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="sources.opml"`)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		io.WriteString(w, xml.Header)
+		encoder := xml.NewEncoder(w)
+		encoder.Indent("", "  ")
+		if err := encoder.Encode(doc); err != nil {
+			log.Printf("OPML export error: %v", err)
+		}
+
+	case http.MethodPost:
+		if !authorizeOPMLImport(w, r) {
+			return
+		}
+		feeds, err := parseOPML(http.MaxBytesReader(w, r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		sourcesUpdateMutex.Lock()
+		merged, added, updated := mergeOPMLFeeds(snapshotSources(), feeds)
+		if err := saveSourcesFile(sourcesFile, merged); err != nil {
+			log.Printf("⚠️  Could not persist imported sources to %s: %v", sourcesFile, err)
+		}
+		replaceSources(merged)
+		sourcesUpdateMutex.Unlock()
+		log.Printf("📥 OPML import: %d outlines, %d added, %d updated", len(feeds), added, updated)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{
+			"outlines": len(feeds),
+			"added":    added,
+			"updated":  updated,
+			"total":    len(merged),
+		})
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
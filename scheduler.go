@@ -0,0 +1,140 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultJitterFraction = 0.1
+	schedulerTick         = time.Second
+)
+
+// sourceSchedule tracks when a source is next due and which settings it was
+// scheduled with, so a reload can tell whether it needs an immediate fetch
+type sourceSchedule struct {
+	Source   FeedSource
+	NextRun  time.Time
+	InFlight bool
+}
+
+// refreshInterval returns the effective interval for a source
+func (s FeedSource) refreshInterval() time.Duration {
+	if s.RefreshInterval > 0 {
+		return s.RefreshInterval
+	}
+	return time.Duration(appConfig.RefreshInterval)
+}
+
+// nextRefreshDelay returns the interval plus a random offset in
+// [-jitter, +jitter] so sources sharing an interval do not fetch in lockstep
+func (s FeedSource) nextRefreshDelay() time.Duration {
+	interval := s.refreshInterval()
+	jitter := s.RefreshJitter
+	if jitter <= 0 {
+		jitter = time.Duration(float64(interval) * defaultJitterFraction)
+	}
+	if jitter <= 0 {
+		return interval
+	}
+	return interval - jitter + time.Duration(rand.Int63n(int64(2*jitter)+1))
+}
+
+// runScheduler refreshes each source on its own interval. Each completed
+// fetch replaces that source's items and rebuilds the shared set, so sources
+// update independently instead of in one global cycle.
+func runScheduler() {
+	schedules := make(map[string]*sourceSchedule)
+	done := make(chan string)
+
+	// Sources were just fetched by fetchAllNews
+	syncSchedules(schedules, time.Now(), false)
+
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sourcesReloaded:
+			removed := syncSchedules(schedules, time.Now(), true)
+			if len(removed) > 0 {
+				dropSourceItems(removed)
+				rebuildNews()
+			}
+
+		case key := <-done:
+			if sched, ok := schedules[key]; ok {
+				sched.InFlight = false
+			}
+
+		case now := <-ticker.C:
+			for key, sched := range schedules {
+				if sched.InFlight || now.Before(sched.NextRun) {
+					continue
+				}
+				// Failing sources back off, or wait out an open circuit
+				if ok, retryAt := breakerAllow(key, now); !ok {
+					sched.NextRun = retryAt
+					continue
+				}
+				sched.InFlight = true
+				sched.NextRun = now.Add(sched.Source.nextRefreshDelay())
+
+				go func(key string, src FeedSource) {
+					defer func() { done <- key }()
+					if fetchSource(key, src) {
+						rebuildNews()
+					}
+				}(key, sched.Source)
+			}
+		}
+	}
+}
+
+// syncSchedules aligns the schedule table with the current sources and
+// returns the keys that were removed or disabled. With dueNow set, new
+// sources and sources whose URL or format changed are fetched right away.
+func syncSchedules(schedules map[string]*sourceSchedule, now time.Time, dueNow bool) []string {
+	sources := snapshotSources()
+
+	var removed []string
+	for key := range schedules {
+		if src, ok := sources[key]; !ok || !src.Enabled {
+			delete(schedules, key)
+			resetBreaker(key)
+			removed = append(removed, key)
+		}
+	}
+
+	for key, src := range sources {
+		if !src.Enabled {
+			continue
+		}
+
+		sched, ok := schedules[key]
+		if !ok {
+			next := now.Add(src.nextRefreshDelay())
+			if dueNow {
+				next = now
+			}
+			schedules[key] = &sourceSchedule{Source: src, NextRun: next}
+			continue
+		}
+
+		previous := sched.Source
+		sched.Source = src
+		switch {
+		case previous.URL != src.URL || previous.Format != src.Format:
+			resetBreaker(key)
+			sched.NextRun = now
+		case previous.refreshInterval() != src.refreshInterval():
+			sched.NextRun = now.Add(src.nextRefreshDelay())
+		}
+	}
+
+	if len(removed) > 0 {
+		log.Printf("🗓️  Unscheduled sources: %v", removed)
+	}
+	return removed
+}
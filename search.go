@@ -0,0 +1,518 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// BM25 parameters and per-field weights for term frequency
+const (
+	bm25K1             = 1.2
+	bm25B              = 0.75
+	titleWeight        = 2.0
+	descriptionWeight  = 1.0
+	keywordWeight      = 1.5
+	fieldPositionGap   = 1000 // keeps phrases from matching across fields
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// posting records where a term occurs in one document
+type posting struct {
+	Weight    float64 // weighted term frequency
+	Positions []int
+}
+
+type indexedDoc struct {
+	Item   NewsItem
+	Length float64
+	Terms  []string
+}
+
+// SearchIndex is an in-process inverted index over title, description and
+// keywords. It is updated incrementally as items are ingested.
+type SearchIndex struct {
+	mu          sync.RWMutex
+	docs        map[string]*indexedDoc
+	postings    map[string]map[string]*posting
+	totalLength float64
+}
+
+var searchIndex = newSearchIndex()
+
+func newSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		docs:     make(map[string]*indexedDoc),
+		postings: make(map[string]map[string]*posting),
+	}
+}
+
+// tokenize lower-cases text and splits it into letter/digit runs
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Add indexes items, replacing any previous version with the same ID
+func (idx *SearchIndex) Add(items []NewsItem) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, item := range items {
+		idx.remove(item.ID)
+
+		doc := &indexedDoc{Item: item}
+		termPostings := make(map[string]*posting)
+		fields := []struct {
+			text   string
+			weight float64
+		}{
+			{item.Title, titleWeight},
+			{item.Description, descriptionWeight},
+			{strings.Join(item.Keywords, " "), keywordWeight},
+		}
+		for f, field := range fields {
+			for pos, term := range tokenize(field.text) {
+				p, ok := termPostings[term]
+				if !ok {
+					p = &posting{}
+					termPostings[term] = p
+				}
+				p.Weight += field.weight
+				p.Positions = append(p.Positions, f*fieldPositionGap+pos)
+				doc.Length += field.weight
+			}
+		}
+
+		for term, p := range termPostings {
+			if idx.postings[term] == nil {
+				idx.postings[term] = make(map[string]*posting)
+			}
+			idx.postings[term][item.ID] = p
+			doc.Terms = append(doc.Terms, term)
+		}
+		idx.docs[item.ID] = doc
+		idx.totalLength += doc.Length
+	}
+}
+
+// remove deletes a document; the caller holds the write lock
+func (idx *SearchIndex) remove(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range doc.Terms {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	idx.totalLength -= doc.Length
+	delete(idx.docs, id)
+}
+
+// Prune drops documents published before cutoff, mirroring store retention
+func (idx *SearchIndex) Prune(cutoff time.Time) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for id, doc := range idx.docs {
+		if doc.Item.PubDate.Before(cutoff) {
+			idx.remove(id)
+		}
+	}
+}
+
+// Len returns the number of indexed documents
+func (idx *SearchIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// SearchResult is one ranked match
+type SearchResult struct {
+	Score float64  `json:"score"`
+	Item  NewsItem `json:"item"`
+}
+
+// Search evaluates a query and returns matches ranked by BM25 score, then
+// by publication date
+func (idx *SearchIndex) Search(query string) ([]SearchResult, error) {
+	expr, err := parseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	scores := expr.eval(idx)
+	results := make([]SearchResult, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, SearchResult{Score: score, Item: idx.docs[id].Item})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Item.PubDate.After(results[j].Item.PubDate)
+		}
+		return results[i].Score > results[j].Score
+	})
+	return results, nil
+}
+
+// termScore returns the BM25 contribution of term for every document containing it
+func (idx *SearchIndex) termScore(term string) map[string]float64 {
+	docsWithTerm := idx.postings[term]
+	if len(docsWithTerm) == 0 {
+		return nil
+	}
+
+	n := float64(len(idx.docs))
+	df := float64(len(docsWithTerm))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+	avgLength := idx.totalLength / n
+
+	scores := make(map[string]float64, len(docsWithTerm))
+	for id, p := range docsWithTerm {
+		norm := 1 - bm25B + bm25B*idx.docs[id].Length/avgLength
+		scores[id] = idf * p.Weight * (bm25K1 + 1) / (p.Weight + bm25K1*norm)
+	}
+	return scores
+}
+
+// Query expression tree
+type searchExpr interface {
+	eval(idx *SearchIndex) map[string]float64
+}
+
+type termExpr struct{ term string }
+type phraseExpr struct{ terms []string }
+type andExpr struct{ left, right searchExpr }
+type orExpr struct{ left, right searchExpr }
+type notExpr struct{ expr searchExpr }
+
+func (e termExpr) eval(idx *SearchIndex) map[string]float64 {
+	return idx.termScore(e.term)
+}
+
+func (e phraseExpr) eval(idx *SearchIndex) map[string]float64 {
+	if len(e.terms) == 1 {
+		return idx.termScore(e.terms[0])
+	}
+
+	scores := idx.termScore(e.terms[0])
+	for _, term := range e.terms[1:] {
+		next := idx.termScore(term)
+		for id := range scores {
+			if s, ok := next[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	// Keep documents where the terms occur at consecutive positions
+	for id := range scores {
+		if !idx.hasPhrase(id, e.terms) {
+			delete(scores, id)
+		}
+	}
+	return scores
+}
+
+func (idx *SearchIndex) hasPhrase(id string, terms []string) bool {
+	for _, start := range idx.postings[terms[0]][id].Positions {
+		matched := true
+		for offset, term := range terms[1:] {
+			if !containsInt(idx.postings[term][id].Positions, start+offset+1) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, target int) bool {
+	i := sort.SearchInts(values, target)
+	return i < len(values) && values[i] == target
+}
+
+func (e andExpr) eval(idx *SearchIndex) map[string]float64 {
+	// "a NOT b" subtracts rather than intersecting with the complement
+	if not, ok := e.right.(notExpr); ok {
+		left := e.left.eval(idx)
+		for id := range not.expr.eval(idx) {
+			delete(left, id)
+		}
+		return left
+	}
+
+	left := e.left.eval(idx)
+	right := e.right.eval(idx)
+	scores := make(map[string]float64)
+	for id, s := range left {
+		if r, ok := right[id]; ok {
+			scores[id] = s + r
+		}
+	}
+	return scores
+}
+
+func (e orExpr) eval(idx *SearchIndex) map[string]float64 {
+	scores := e.left.eval(idx)
+	if scores == nil {
+		scores = make(map[string]float64)
+	}
+	for id, s := range e.right.eval(idx) {
+		scores[id] += s
+	}
+	return scores
+}
+
+func (e notExpr) eval(idx *SearchIndex) map[string]float64 {
+	excluded := e.expr.eval(idx)
+	scores := make(map[string]float64)
+	for id := range idx.docs {
+		if _, ok := excluded[id]; !ok {
+			scores[id] = 0
+		}
+	}
+	return scores
+}
+
+// parseSearchQuery parses queries such as
+//
+//	tata motors "quarterly results" OR (infy AND NOT wipro) -crypto
+//
+// Adjacent terms are ANDed. AND, OR and NOT must be upper case; a leading
+// '-' negates a term, phrase or group.
+func parseSearchQuery(query string) (searchExpr, error) {
+	tokens, err := lexSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	p := &queryParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return expr, nil
+}
+
+type queryToken struct {
+	kind string // "term", "phrase", "(", ")", "AND", "OR", "NOT"
+	text string
+}
+
+func lexSearchQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, queryToken{kind: string(r)})
+			i++
+		case r == '-':
+			tokens = append(tokens, queryToken{kind: "NOT"})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated phrase")
+			}
+			tokens = append(tokens, queryToken{kind: "phrase", text: string(runes[i+1 : end])})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`()"`, runes[end]) {
+				end++
+			}
+			word := string(runes[i:end])
+			switch word {
+			case "AND", "OR", "NOT":
+				tokens = append(tokens, queryToken{kind: word})
+			default:
+				tokens = append(tokens, queryToken{kind: "term", text: word})
+			}
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].kind
+	}
+	return ""
+}
+
+func (p *queryParser) parseOr() (searchExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "OR" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (searchExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek() {
+		case "AND":
+			p.pos++
+		case "term", "phrase", "(", "NOT":
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+}
+
+func (p *queryParser) parseUnary() (searchExpr, error) {
+	if p.peek() == "NOT" {
+		p.pos++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (searchExpr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch tok.kind {
+	case "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return expr, nil
+	case "term", "phrase":
+		terms := tokenize(tok.text)
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("%q has no searchable words", tok.text)
+		}
+		if len(terms) == 1 {
+			return termExpr{terms[0]}, nil
+		}
+		// Words joined by punctuation, e.g. "bajaj-auto", match as a phrase
+		return phraseExpr{terms}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", tok.kind)
+	}
+}
+
+// loadSearchIndex indexes the stored articles at startup
+func loadSearchIndex() {
+	items, err := articleStore.Range(time.Time{}, time.Time{})
+	if err != nil {
+		log.Printf("❌ Could not load stored articles into the search index: %v", err)
+		return
+	}
+	searchIndex.Add(items)
+	log.Printf("🔎 Search index loaded with %d articles", len(items))
+}
+
+// searchHandler serves /api/search?q=...&limit=N
+func searchHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		http.Error(w, "missing q parameter", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultSearchLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	results, err := searchIndex.Search(q)
+	if err != nil {
+		http.Error(w, "invalid query: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total := len(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	for i := range results {
+		results[i].Item.TimeAgo = timeAgo(results[i].Item.PubDate)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"query":   q,
+		"total":   total,
+		"results": results,
+	})
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Duration is a time.Duration that reads and writes JSON as "5m", "90s", etc.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"5m\": %v", err)
+	}
+	if s == "" {
+		*d = 0
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// SourceConfig is one entry of the sources file
+type SourceConfig struct {
+	Key             string            `json:"key"`
+	URL             string            `json:"url"`
+	Color           string            `json:"color,omitempty"`
+	Name            string            `json:"name,omitempty"`
+	Format          string            `json:"format,omitempty"`
+	Category        string            `json:"category,omitempty"`
+	RefreshInterval Duration          `json:"refresh_interval,omitempty"`
+	RefreshJitter   Duration          `json:"refresh_jitter,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Enabled         *bool             `json:"enabled,omitempty"`
+}
+
+// SourcesFile is the on-disk layout of the sources configuration
+type SourcesFile struct {
+	Sources []SourceConfig `json:"sources"`
+}
+
+const (
+	defaultSourceColor       = "#6b7280"
+	minSourceRefreshInterval = 30 * time.Second
+	sourcesPollInterval      = 10 * time.Second
+)
+
+var (
+	sourcesMutex   sync.RWMutex
+	sourcesFile    string
+	sourcesModTime time.Time
+
+	// sourcesUpdateMutex serializes whole updates of the source list, from
+	// reading the current sources to replacing them, so a reload and an OPML
+	// import cannot overwrite each other
+	sourcesUpdateMutex sync.Mutex
+
+	// sourcesReloaded is signalled after the source list changes so the
+	// fetch loop can pick up new sources without waiting a full cycle
+	sourcesReloaded = make(chan struct{}, 1)
+
+	hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// snapshotSources returns a copy of the source registry that is safe to
+// range over while a reload is in progress
+func snapshotSources() map[string]FeedSource {
+	sourcesMutex.RLock()
+	defer sourcesMutex.RUnlock()
+
+	snapshot := make(map[string]FeedSource, len(rssSources))
+	for key, src := range rssSources {
+		snapshot[key] = src
+	}
+	return snapshot
+}
+
+// lookupSource returns the current settings of one source
+func lookupSource(key string) (FeedSource, bool) {
+	sourcesMutex.RLock()
+	defer sourcesMutex.RUnlock()
+
+	src, ok := rssSources[key]
+	return src, ok
+}
+
+// enabledSourceCount returns the number of sources that are being fetched
+func enabledSourceCount() int {
+	sourcesMutex.RLock()
+	defer sourcesMutex.RUnlock()
+
+	count := 0
+	for _, src := range rssSources {
+		if src.Enabled {
+			count++
+		}
+	}
+	return count
+}
+
+// loadSourcesFile reads and validates a sources file
+func loadSourcesFile(path string) (map[string]FeedSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file SourcesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+
+	return buildSources(file.Sources)
+}
+
+// buildSources validates source entries and turns them into a registry,
+// reporting every invalid entry rather than just the first
+func buildSources(entries []SourceConfig) (map[string]FeedSource, error) {
+	sources := make(map[string]FeedSource, len(entries))
+	var errs []error
+
+	for i, entry := range entries {
+		src, err := entry.toFeedSource()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source #%d (%q): %v", i+1, entry.Key, err))
+			continue
+		}
+		if _, exists := sources[entry.Key]; exists {
+			errs = append(errs, fmt.Errorf("source #%d: duplicate key %q", i+1, entry.Key))
+			continue
+		}
+		sources[entry.Key] = src
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no sources defined")
+	}
+	return sources, nil
+}
+
+func (c SourceConfig) toFeedSource() (FeedSource, error) {
+	if c.Key == "" {
+		return FeedSource{}, fmt.Errorf("key is required")
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return FeedSource{}, fmt.Errorf("url must be an absolute http(s) URL, got %q", c.URL)
+	}
+
+	color := c.Color
+	if color == "" {
+		color = defaultSourceColor
+	}
+	if !hexColorPattern.MatchString(color) {
+		return FeedSource{}, fmt.Errorf("color must look like #rrggbb, got %q", c.Color)
+	}
+
+	if c.Format != "" && c.Format != "auto" {
+		if _, ok := feedParsers[c.Format]; !ok {
+			return FeedSource{}, fmt.Errorf("unknown format %q", c.Format)
+		}
+	}
+
+	interval := time.Duration(c.RefreshInterval)
+	if interval < 0 || (interval > 0 && interval < minSourceRefreshInterval) {
+		return FeedSource{}, fmt.Errorf("refresh_interval must be at least %s", minSourceRefreshInterval)
+	}
+
+	jitter := time.Duration(c.RefreshJitter)
+	if jitter < 0 {
+		return FeedSource{}, fmt.Errorf("refresh_jitter must not be negative")
+	}
+	if effective := (FeedSource{RefreshInterval: interval}).refreshInterval(); jitter >= effective {
+		return FeedSource{}, fmt.Errorf("refresh_jitter must be shorter than the refresh interval (%s)", effective)
+	}
+
+	for header := range c.Headers {
+		if header == "" {
+			return FeedSource{}, fmt.Errorf("header names must not be empty")
+		}
+	}
+
+	name := c.Name
+	if name == "" {
+		name = c.Key
+	}
+
+	enabled := true
+	if c.Enabled != nil {
+		enabled = *c.Enabled
+	}
+
+	return FeedSource{
+		URL:             c.URL,
+		Color:           color,
+		Name:            name,
+		Format:          c.Format,
+		Category:        c.Category,
+		RefreshInterval: interval,
+		RefreshJitter:   jitter,
+		Headers:         c.Headers,
+		Enabled:         enabled,
+	}, nil
+}
+
+// sourceConfigs converts the registry back into file entries sorted by key
+func sourceConfigs(sources map[string]FeedSource) []SourceConfig {
+	entries := make([]SourceConfig, 0, len(sources))
+	for key, src := range sources {
+		enabled := src.Enabled
+		entries = append(entries, SourceConfig{
+			Key:             key,
+			URL:             src.URL,
+			Color:           src.Color,
+			Name:            src.Name,
+			Format:          src.Format,
+			Category:        src.Category,
+			RefreshInterval: Duration(src.RefreshInterval),
+			RefreshJitter:   Duration(src.RefreshJitter),
+			Headers:         src.Headers,
+			Enabled:         &enabled,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// saveSourcesFile writes the registry to path atomically and records the new
+// modification time so the watcher does not reload our own write
+func saveSourcesFile(path string, sources map[string]FeedSource) error {
+	data, err := json.MarshalIndent(SourcesFile{Sources: sourceConfigs(sources)}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		sourcesMutex.Lock()
+		sourcesModTime = info.ModTime()
+		sourcesMutex.Unlock()
+	}
+	return nil
+}
+
+// replaceSources swaps in a new registry and drops cached responses and
+// telemetry for sources that no longer exist
+func replaceSources(sources map[string]FeedSource) {
+	sourcesMutex.Lock()
+	rssSources = sources
+	sourcesMutex.Unlock()
+
+	feedCacheMutex.Lock()
+	for key := range feedCache {
+		if _, ok := sources[key]; !ok {
+			delete(feedCache, key)
+		}
+	}
+	feedCacheMutex.Unlock()
+
+	sourceHealthMutex.Lock()
+	for key := range sourceHealth {
+		if _, ok := sources[key]; !ok {
+			delete(sourceHealth, key)
+		}
+	}
+	sourceHealthMutex.Unlock()
+
+	select {
+	case sourcesReloaded <- struct{}{}:
+	default:
+	}
+}
+
+// initSources loads the sources file if it exists, otherwise keeps the
+// built-in defaults. An invalid file at startup is fatal.
+func initSources(path string) {
+	sourcesFile = path
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		log.Printf("📄 No sources file at %s, using %d built-in sources", path, len(rssSources))
+		return
+	}
+	if err != nil {
+		log.Fatalf("Cannot read sources file %s: %v", path, err)
+	}
+
+	sources, err := loadSourcesFile(path)
+	if err != nil {
+		log.Fatalf("Invalid sources file %s: %v", path, err)
+	}
+
+	sourcesMutex.Lock()
+	rssSources = sources
+	sourcesModTime = info.ModTime()
+	sourcesMutex.Unlock()
+
+	log.Printf("📄 Loaded %d sources from %s", len(sources), path)
+}
+
+// reloadSources re-reads the sources file. On error the current sources
+// stay in place.
+func reloadSources(reason string) {
+	sourcesUpdateMutex.Lock()
+	defer sourcesUpdateMutex.Unlock()
+
+	info, err := os.Stat(sourcesFile)
+	if err != nil {
+		log.Printf("❌ Sources reload (%s) skipped: %v", reason, err)
+		return
+	}
+
+	// Record the modification time even for a rejected file so the watcher
+	// does not retry the same broken revision every poll
+	sourcesMutex.Lock()
+	sourcesModTime = info.ModTime()
+	sourcesMutex.Unlock()
+
+	sources, err := loadSourcesFile(sourcesFile)
+	if err != nil {
+		log.Printf("❌ Sources reload (%s) rejected, keeping current sources: %v", reason, err)
+		return
+	}
+
+	replaceSources(sources)
+	log.Printf("🔁 Reloaded %d sources from %s (%s)", len(sources), sourcesFile, reason)
+}
+
+// watchSources reloads the sources file on SIGHUP and whenever its
+// modification time changes
+func watchSources() {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	ticker := time.NewTicker(sourcesPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hangup:
+			reloadSources("SIGHUP")
+		case <-ticker.C:
+			info, err := os.Stat(sourcesFile)
+			if err != nil {
+				continue
+			}
+			sourcesMutex.RLock()
+			changed := !info.ModTime().Equal(sourcesModTime)
+			sourcesMutex.RUnlock()
+			if changed {
+				reloadSources("file changed")
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+var (
+	startTime      = time.Now()
+	firstFetchDone bool // guarded by newsMutex
+)
+
+// StatusResponse is the body of /api/status
+type StatusResponse struct {
+	Status           string         `json:"status"` // "ok", "starting" or "stale"
+	Ready            bool           `json:"ready"`
+	Live             bool           `json:"live"`
+	Uptime           string         `json:"uptime"`
+	LastFetch        time.Time      `json:"last_fetch"`
+	LastSuccess      time.Time      `json:"last_success"`
+	DataAgeSeconds   float64        `json:"data_age_seconds"`
+	Articles         int            `json:"articles"`
+	WebSocketClients int            `json:"websocket_clients"`
+	Sources          int            `json:"sources"`
+	SourceHealth     map[string]int `json:"source_health"`
+}
+
+// statusHandler reports readiness and data freshness. It returns 503 until
+// the first fetch completes and whenever no source has been fetched
+// successfully within staleDataThreshold(), so container health checks catch
+// a stuck fetcher or lost connectivity.
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+	newsMutex.RLock()
+	ready := firstFetchDone
+	lastFetch := lastFetchTime
+	articles := len(currentNews)
+	newsMutex.RUnlock()
+
+	wsClients := broadcastHub.Count(kindWebSocket)
+
+	// Freshness is measured from the most recent successful source fetch;
+	// lastFetchTime also advances when every source failed
+	var lastSuccess time.Time
+	healthCounts := make(map[string]int)
+	for _, h := range collectSourceHealth("") {
+		healthCounts[h.Status]++
+		if h.LastSuccess.After(lastSuccess) {
+			lastSuccess = h.LastSuccess
+		}
+	}
+
+	resp := StatusResponse{
+		Status:           "ok",
+		Ready:            ready,
+		Live:             true,
+		Uptime:           time.Since(startTime).Round(time.Second).String(),
+		LastFetch:        lastFetch,
+		LastSuccess:      lastSuccess,
+		Articles:         articles,
+		WebSocketClients: wsClients,
+		Sources:          enabledSourceCount(),
+		SourceHealth:     healthCounts,
+	}
+	if !lastSuccess.IsZero() {
+		resp.DataAgeSeconds = time.Since(lastSuccess).Seconds()
+	}
+
+	code := http.StatusOK
+	switch {
+	case !ready:
+		resp.Status = "starting"
+		code = http.StatusServiceUnavailable
+	case lastSuccess.IsZero() || time.Since(lastSuccess) > staleDataThreshold():
+		resp.Status = "stale"
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// apiNotFoundHandler keeps unknown /api/ paths from falling through to the
+// HTML home page
+func apiNotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]string{"error": "unknown API endpoint " + r.URL.Path})
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ArticleStore persists articles across refreshes so history survives
+// items dropping out of their feeds
+type ArticleStore interface {
+	// Upsert inserts or replaces items by ID
+	Upsert(items []NewsItem) error
+	// Range returns items published in [since, until), newest first.
+	// A zero bound is open.
+	Range(since, until time.Time) ([]NewsItem, error)
+	// Prune deletes items published before cutoff and returns how many
+	Prune(cutoff time.Time) (int, error)
+	Close() error
+}
+
+var articleStore ArticleStore = newMemoryStore()
+
+// openArticleStore opens the bbolt store at path, or an in-memory store when
+// path is empty or the file cannot be opened
+func openArticleStore(path string) ArticleStore {
+	if path == "" {
+		log.Println("💾 Article store: in-memory (no store path configured)")
+		return newMemoryStore()
+	}
+
+	store, err := newBoltStore(path)
+	if err != nil {
+		log.Printf("⚠️  Could not open article store %s, falling back to memory: %v", path, err)
+		return newMemoryStore()
+	}
+	log.Printf("💾 Article store: %s", path)
+	return store
+}
+
+// retentionLoop prunes the store and search index every hour
+func retentionLoop() {
+	prune := func() {
+		cutoff := time.Now().Add(-time.Duration(appConfig.Retention))
+		searchIndex.Prune(cutoff)
+		n, err := articleStore.Prune(cutoff)
+		if err != nil {
+			log.Printf("❌ Article retention failed: %v", err)
+		} else if n > 0 {
+			log.Printf("🧹 Pruned %d articles older than %s", n, time.Duration(appConfig.Retention))
+		}
+	}
+
+	prune()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		prune()
+	}
+}
+
+// memoryStore keeps articles in a map; used when no store path is configured
+type memoryStore struct {
+	mu    sync.RWMutex
+	items map[string]NewsItem
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{items: make(map[string]NewsItem)}
+}
+
+func (s *memoryStore) Upsert(items []NewsItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range items {
+		s.items[item.ID] = item
+	}
+	return nil
+}
+
+func (s *memoryStore) Range(since, until time.Time) ([]NewsItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []NewsItem
+	for _, item := range s.items {
+		if inRange(item.PubDate, since, until) {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].PubDate.After(items[j].PubDate)
+	})
+	return items, nil
+}
+
+func (s *memoryStore) Prune(cutoff time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for id, item := range s.items {
+		if item.PubDate.Before(cutoff) {
+			delete(s.items, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memoryStore) Close() error { return nil }
+
+func inRange(t, since, until time.Time) bool {
+	if !since.IsZero() && t.Before(since) {
+		return false
+	}
+	if !until.IsZero() && !t.Before(until) {
+		return false
+	}
+	return true
+}
+
+var (
+	articlesBucket = []byte("articles") // ID -> JSON NewsItem
+	byTimeBucket   = []byte("by_time")  // pub date (8 bytes) + ID -> nil
+)
+
+// boltStore persists articles in a single bbolt file with a secondary index
+// on publication time for range scans and pruning
+type boltStore struct {
+	db *bolt.DB
+}
+
+func newBoltStore(path string) (*boltStore, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(articlesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(byTimeBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltStore{db: db}, nil
+}
+
+func timeKey(t time.Time, id string) []byte {
+	key := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return append(key, id...)
+}
+
+func (s *boltStore) Upsert(items []NewsItem) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		articles := tx.Bucket(articlesBucket)
+		byTime := tx.Bucket(byTimeBucket)
+
+		for _, item := range items {
+			id := []byte(item.ID)
+
+			// Drop the old index entry when the publication date moved
+			if old := articles.Get(id); old != nil {
+				var previous NewsItem
+				if err := json.Unmarshal(old, &previous); err == nil && !previous.PubDate.Equal(item.PubDate) {
+					if err := byTime.Delete(timeKey(previous.PubDate, item.ID)); err != nil {
+						return err
+					}
+				}
+			}
+
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if err := articles.Put(id, data); err != nil {
+				return err
+			}
+			if err := byTime.Put(timeKey(item.PubDate, item.ID), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStore) Range(since, until time.Time) ([]NewsItem, error) {
+	var items []NewsItem
+	err := s.db.View(func(tx *bolt.Tx) error {
+		articles := tx.Bucket(articlesBucket)
+		c := tx.Bucket(byTimeBucket).Cursor()
+
+		var k []byte
+		if until.IsZero() {
+			k, _ = c.Last()
+		} else {
+			// Position on the first key at or after until, then step back
+			k, _ = c.Seek(timeKey(until, ""))
+			if k == nil {
+				k, _ = c.Last()
+			} else {
+				k, _ = c.Prev()
+			}
+		}
+
+		var lower []byte
+		if !since.IsZero() {
+			lower = timeKey(since, "")
+		}
+
+		for ; k != nil; k, _ = c.Prev() {
+			if lower != nil && bytes.Compare(k, lower) < 0 {
+				break
+			}
+			data := articles.Get(k[8:])
+			if data == nil {
+				continue
+			}
+			var item NewsItem
+			if err := json.Unmarshal(data, &item); err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		return nil
+	})
+	return items, err
+}
+
+func (s *boltStore) Prune(cutoff time.Time) (int, error) {
+	n := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		articles := tx.Bucket(articlesBucket)
+		byTime := tx.Bucket(byTimeBucket)
+		upper := timeKey(cutoff, "")
+
+		// Collect first: deleting through a cursor while iterating skips keys
+		var expired [][]byte
+		c := byTime.Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k, upper) < 0; k, _ = c.Next() {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+
+		for _, k := range expired {
+			if err := articles.Delete(k[8:]); err != nil {
+				return err
+			}
+			if err := byTime.Delete(k); err != nil {
+				return err
+			}
+		}
+		n = len(expired)
+		return nil
+	})
+	return n, err
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Interval between SSE keepalive comments, short enough to stop idle
+// proxies from closing the connection
+const streamKeepalive = 15 * time.Second
+
+// streamHandler serves /api/stream, pushing the same payload as the
+// WebSocket as Server-Sent Events. The first event is a "snapshot" unless
+// the client resumes with a Last-Event-ID whose missed events are still in
+// the replay buffer, in which case only those are sent; subsequent events
+// are "delta" (see NewsDelta).
+func streamHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	fmt.Fprintf(w, "retry: %d\n\n", 5000)
+
+	// EventSource sends Last-Event-ID on reconnect; the query parameter
+	// covers clients that cannot set headers
+	resumeID := r.Header.Get("Last-Event-ID")
+	if resumeID == "" {
+		resumeID = r.URL.Query().Get("last_event_id")
+	}
+
+	var (
+		sub     *Subscriber
+		lastID  uint64
+		missed  []BroadcastEvent
+		resumed bool
+	)
+	// IDs from an earlier server process fail the epoch check in Resume
+	if since, err := parseResumeToken(resumeID); err == nil {
+		sub, lastID, missed, resumed = broadcastHub.Resume(kindStream, since)
+	} else {
+		sub, lastID = broadcastHub.Subscribe(kindStream)
+	}
+	defer broadcastHub.Unsubscribe(sub)
+
+	if resumed {
+		for _, event := range missed {
+			if err := writeSSE(w, event); err != nil {
+				return
+			}
+		}
+	} else if err := writeSSESnapshot(w, lastID); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	log.Printf("📡 Stream client connected. Total stream clients: %d", broadcastHub.Count(kindStream))
+	defer log.Printf("📡 Stream client disconnected")
+
+	keepalive := time.NewTicker(streamKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Dropped by the hub; the client resumes with Last-Event-ID
+				return
+			}
+			if err := writeSSE(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSSESnapshot(w http.ResponseWriter, id uint64) error {
+	data, err := json.Marshal(currentNewsData())
+	if err != nil {
+		return err
+	}
+	return writeSSE(w, BroadcastEvent{ID: id, Type: "snapshot", Data: data})
+}
+
+// writeSSE writes one event; JSON payloads never contain raw newlines so
+// a single data line suffices
+func writeSSE(w http.ResponseWriter, event BroadcastEvent) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", broadcastHub.Token(event.ID), event.Type, event.Data)
+	return err
+}
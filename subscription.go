@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ClientMessage is a control message sent by a WebSocket client:
+//
+//	{"action": "subscribe", "id": "1", "sources": ["ZP"], "stocks": ["TCS"]}
+//	{"action": "unsubscribe", "stocks": ["TCS"]}
+//	{"action": "unsubscribe"}
+//	{"action": "resync"}
+//
+// Subscribing adds values and unsubscribing removes them; an unsubscribe
+// without values clears the subscription. Resync requests a fresh snapshot
+// after a gap in sequence numbers. Values use the same syntax as the
+// matching /filter parameters, so "!ZP" excludes a source.
+type ClientMessage struct {
+	Action string `json:"action"`
+	ID     string `json:"id,omitempty"`
+	Subscription
+}
+
+// Subscription is the per-connection filter. A client with an empty
+// subscription receives every item.
+type Subscription struct {
+	Sources    []string `json:"sources,omitempty"`
+	Stocks     []string `json:"stocks,omitempty"`
+	Sentiments []string `json:"sentiments,omitempty"`
+	Keywords   []string `json:"keywords,omitempty"`
+}
+
+// SubscriptionReply acknowledges a client message or reports why it failed
+type SubscriptionReply struct {
+	Type         string        `json:"type"` // "ack" or "error"
+	Action       string        `json:"action,omitempty"`
+	ID           string        `json:"id,omitempty"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// fields pairs each subscription list with its /filter parameter name
+func (s *Subscription) fields() map[string]*[]string {
+	return map[string]*[]string{
+		"source":    &s.Sources,
+		"stock":     &s.Stocks,
+		"sentiment": &s.Sentiments,
+		"keyword":   &s.Keywords,
+	}
+}
+
+// Empty reports whether the subscription matches everything
+func (s Subscription) Empty() bool {
+	for _, values := range s.fields() {
+		if len(*values) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the subscription updated by msg
+func (s Subscription) Apply(msg ClientMessage) (Subscription, error) {
+	next := Subscription{}
+	current := s.fields()
+	changes := msg.Subscription.fields()
+
+	for name, values := range next.fields() {
+		switch msg.Action {
+		case "subscribe":
+			*values = addValues(*current[name], *changes[name])
+		case "unsubscribe":
+			if msg.Subscription.Empty() {
+				continue
+			}
+			*values = removeValues(*current[name], *changes[name])
+		default:
+			return s, fmt.Errorf("unknown action %q", msg.Action)
+		}
+	}
+	return next, nil
+}
+
+// Filter converts the subscription into the shared filter representation;
+// it returns nil for an empty subscription
+func (s Subscription) Filter() (*ItemFilter, error) {
+	if s.Empty() {
+		return nil, nil
+	}
+	params := url.Values{}
+	for name, values := range s.fields() {
+		params[name] = *values
+	}
+	f, err := parseItemFilter(params)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func addValues(values, add []string) []string {
+	result := append([]string(nil), values...)
+	for _, v := range add {
+		if v = strings.TrimSpace(v); v != "" && indexFold(result, v) < 0 {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func removeValues(values, remove []string) []string {
+	var result []string
+	for _, v := range values {
+		if indexFold(remove, v) < 0 {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func indexFold(values []string, v string) int {
+	for i, existing := range values {
+		if strings.EqualFold(strings.TrimSpace(existing), v) {
+			return i
+		}
+	}
+	return -1
+}
+
+// filterNewsData returns a copy of data holding only the items matching f
+func filterNewsData(data NewsData, f *ItemFilter) NewsData {
+	items := make([]NewsItem, 0, len(data.Items))
+	for _, item := range data.Items {
+		if f.Match(item) {
+			items = append(items, item)
+		}
+	}
+	data.Items = items
+	return data
+}
+
+// encodeForClient encodes a snapshot or delta for one client, applying its
+// filter
+func encodeForClient(payload interface{}, f *ItemFilter) ([]byte, error) {
+	if f != nil {
+		switch p := payload.(type) {
+		case NewsData:
+			payload = filterNewsData(p, f)
+		case NewsDelta:
+			payload = filterDelta(p, f)
+		}
+	}
+	return json.Marshal(payload)
+}
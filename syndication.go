@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"time"
+)
+
+const feedTitle = "Business News Aggregator"
+
+// RSS 2.0 output. The parsing structs in main.go are deliberately lax, so
+// output uses its own types.
+type rssOutput struct {
+	XMLName xml.Name         `xml:"rss"`
+	Version string           `xml:"version,attr"`
+	AtomNS  string           `xml:"xmlns:atom,attr"`
+	Channel rssOutputChannel `xml:"channel"`
+}
+
+type rssOutputChannel struct {
+	Title         string          `xml:"title"`
+	Link          string          `xml:"link"`
+	Description   string          `xml:"description"`
+	LastBuildDate string          `xml:"lastBuildDate"`
+	SelfLink      rssSelfLink     `xml:"atom:link"`
+	Items         []rssOutputItem `xml:"item"`
+}
+
+type rssSelfLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssOutputItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link,omitempty"`
+	Description string        `xml:"description,omitempty"`
+	PubDate     string        `xml:"pubDate"`
+	GUID        GUID          `xml:"guid"`
+	Categories  []rssCategory `xml:"category"`
+	Source      *rssSource    `xml:"source,omitempty"`
+}
+
+type rssCategory struct {
+	Domain string `xml:"domain,attr,omitempty"`
+	Value  string `xml:",chardata"`
+}
+
+type rssSource struct {
+	URL  string `xml:"url,attr"`
+	Name string `xml:",chardata"`
+}
+
+// Atom output
+type atomOutput struct {
+	XMLName xml.Name          `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string            `xml:"id"`
+	Title   string            `xml:"title"`
+	Updated string            `xml:"updated"`
+	Links   []AtomLink        `xml:"link"`
+	Entries []atomOutputEntry `xml:"entry"`
+}
+
+type atomOutputEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []AtomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     atomPerson     `xml:"author"`
+	Summary    string         `xml:"summary,omitempty"`
+	Categories []AtomCategory `xml:"category"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+// JSON Feed 1.1 output. Sentiment and priority go in an extension object.
+type jsonFeedOutput struct {
+	Version     string               `json:"version"`
+	Title       string               `json:"title"`
+	HomePageURL string               `json:"home_page_url"`
+	FeedURL     string               `json:"feed_url"`
+	Items       []jsonFeedOutputItem `json:"items"`
+}
+
+type jsonFeedOutputItem struct {
+	ID            string            `json:"id"`
+	URL           string            `json:"url,omitempty"`
+	Title         string            `json:"title"`
+	ContentText   string            `json:"content_text"`
+	Summary       string            `json:"summary,omitempty"`
+	DatePublished string            `json:"date_published"`
+	Authors       []jsonFeedAuthor  `json:"authors"`
+	Tags          []string          `json:"tags,omitempty"`
+	Market        jsonFeedExtension `json:"_market"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedExtension struct {
+	Source         string  `json:"source"`
+	SentimentLabel string  `json:"sentiment_label"`
+	SentimentScore float64 `json:"sentiment_score"`
+	Nifty50Stock   string  `json:"nifty50_stock,omitempty"`
+	Priority       int     `json:"priority"`
+}
+
+// feedCategory is one categorisation of an item, rendered as an RSS
+// category domain, an Atom category scheme or a JSON Feed tag prefix
+type feedCategory struct {
+	Scheme string
+	Value  string
+}
+
+func itemCategories(item NewsItem) []feedCategory {
+	categories := []feedCategory{{Scheme: "source", Value: item.SourceName}}
+	if item.Category != "" {
+		categories = append(categories, feedCategory{Scheme: "category", Value: item.Category})
+	}
+	if item.SentimentLabel != "" {
+		categories = append(categories, feedCategory{Scheme: "sentiment", Value: item.SentimentLabel})
+	}
+	if item.Nifty50Stock != "" {
+		categories = append(categories, feedCategory{Scheme: "nifty50", Value: item.Nifty50Stock})
+	}
+	return categories
+}
+
+// syndicationItems returns the live, deduplicated stream matching the
+// request's filter parameters, and when it was last updated
+func syndicationItems(w http.ResponseWriter, r *http.Request) ([]NewsItem, time.Time, bool) {
+	filter, err := parseItemFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, time.Time{}, false
+	}
+
+	newsMutex.RLock()
+	items := collapseClusters(currentNews, liveClusters)
+	updated := lastFetchTime
+	newsMutex.RUnlock()
+
+	var matched []NewsItem
+	for _, item := range items {
+		if filter.Match(item) {
+			matched = append(matched, item)
+		}
+	}
+	return matched, updated, true
+}
+
+// requestBaseURL reconstructs the externally visible scheme and host
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// rssFeedHandler serves /feed.rss
+func rssFeedHandler(w http.ResponseWriter, r *http.Request) {
+	items, updated, ok := syndicationItems(w, r)
+	if !ok {
+		return
+	}
+	base := requestBaseURL(r)
+
+	feed := rssOutput{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssOutputChannel{
+			Title:         feedTitle,
+			Link:          base + "/",
+			Description:   "Prioritized Indian business and market news from multiple sources",
+			LastBuildDate: updated.Format(time.RFC1123Z),
+			SelfLink:      rssSelfLink{Href: base + r.URL.RequestURI(), Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	for _, item := range items {
+		out := rssOutputItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			PubDate:     item.PubDate.Format(time.RFC1123Z),
+			GUID:        GUID{Value: item.ID, IsPermaLink: "false"},
+		}
+		for _, c := range itemCategories(item) {
+			out.Categories = append(out.Categories, rssCategory{Domain: c.Scheme, Value: c.Value})
+		}
+		if src, ok := lookupSource(item.Source); ok {
+			out.Source = &rssSource{URL: src.URL, Name: item.SourceName}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, out)
+	}
+
+	writeXMLFeed(w, "application/rss+xml; charset=utf-8", feed)
+}
+
+// atomFeedHandler serves /feed.atom
+func atomFeedHandler(w http.ResponseWriter, r *http.Request) {
+	items, updated, ok := syndicationItems(w, r)
+	if !ok {
+		return
+	}
+	base := requestBaseURL(r)
+
+	feed := atomOutput{
+		ID:      base + "/feed.atom",
+		Title:   feedTitle,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Links: []AtomLink{
+			{Href: base + r.URL.RequestURI(), Rel: "self", Type: "application/atom+xml"},
+			{Href: base + "/", Rel: "alternate", Type: "text/html"},
+		},
+	}
+	for _, item := range items {
+		published := item.PubDate.UTC().Format(time.RFC3339)
+		entry := atomOutputEntry{
+			ID:        "urn:rss-aggregator:article:" + item.ID,
+			Title:     item.Title,
+			Published: published,
+			Updated:   published,
+			Author:    atomPerson{Name: item.SourceName},
+			Summary:   item.Description,
+		}
+		if item.Link != "" {
+			entry.Links = []AtomLink{{Href: item.Link, Rel: "alternate", Type: "text/html"}}
+		}
+		for _, c := range itemCategories(item) {
+			entry.Categories = append(entry.Categories, AtomCategory{Term: c.Value, Scheme: c.Scheme, Label: c.Scheme + ": " + c.Value})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	writeXMLFeed(w, "application/atom+xml; charset=utf-8", feed)
+}
+
+// jsonFeedHandler serves /feed.json
+func jsonFeedHandler(w http.ResponseWriter, r *http.Request) {
+	items, _, ok := syndicationItems(w, r)
+	if !ok {
+		return
+	}
+	base := requestBaseURL(r)
+
+	feed := jsonFeedOutput{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feedTitle,
+		HomePageURL: base + "/",
+		FeedURL:     base + r.URL.RequestURI(),
+		Items:       []jsonFeedOutputItem{},
+	}
+	for _, item := range items {
+		out := jsonFeedOutputItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentText:   item.Description,
+			Summary:       item.Summary,
+			DatePublished: item.PubDate.Format(time.RFC3339),
+			Authors:       []jsonFeedAuthor{{Name: item.SourceName}},
+			Market: jsonFeedExtension{
+				Source:         item.Source,
+				SentimentLabel: item.SentimentLabel,
+				SentimentScore: item.SentimentScore,
+				Nifty50Stock:   item.Nifty50Stock,
+				Priority:       item.Priority,
+			},
+		}
+		for _, c := range itemCategories(item) {
+			out.Tags = append(out.Tags, c.Scheme+":"+c.Value)
+		}
+		feed.Items = append(feed.Items, out)
+	}
+
+	w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(feed)
+}
+
+func writeXMLFeed(w http.ResponseWriter, contentType string, feed interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(feed)
+}
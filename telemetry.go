@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const fetchHistorySize = 20 // fetch records kept per source
+
+// Source health classifications
+const (
+	healthUnknown = "unknown" // not fetched yet
+	healthHealthy = "healthy" // fetching and publishing recent items
+	healthQuiet   = "quiet"   // fetching fine but no items in the last 24 hours
+	healthFailing = "failing" // last fetch failed, retrying with backoff
+	healthDown    = "down"    // circuit breaker open
+)
+
+// FetchRecord describes a single fetchRSSFeed call
+type FetchRecord struct {
+	Time        time.Time `json:"time"`
+	DurationMS  int64     `json:"duration_ms"`
+	Status      int       `json:"status"` // 0 when no HTTP response was received
+	Bytes       int64     `json:"bytes"`
+	Items       int       `json:"items"`
+	NotModified bool      `json:"not_modified"`
+	ParseError  bool      `json:"parse_error"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// SourceHealth aggregates the fetch telemetry of one source
+type SourceHealth struct {
+	Key          string        `json:"key"`
+	Name         string        `json:"name"`
+	Status       string        `json:"status"`
+	Fetches      int           `json:"fetches"`
+	Failures     int           `json:"failures"`
+	ParseErrors  int           `json:"parse_errors"`
+	BytesTotal   int64         `json:"bytes_total"`
+	LastStatus   int           `json:"last_status"`
+	LastAttempt  time.Time     `json:"last_attempt"`
+	LastSuccess  time.Time     `json:"last_success"`
+	LastItems    int           `json:"last_items"`
+	LiveItems    int           `json:"live_items"`
+	NewestItem   time.Time     `json:"newest_item"`
+	AvgLatencyMS float64       `json:"avg_latency_ms"`
+	History      []FetchRecord `json:"history"`
+}
+
+var (
+	sourceHealth      = make(map[string]*SourceHealth)
+	sourceHealthMutex sync.Mutex
+)
+
+func getSourceHealth(key string) *SourceHealth {
+	h, ok := sourceHealth[key]
+	if !ok {
+		h = &SourceHealth{Key: key}
+		sourceHealth[key] = h
+	}
+	return h
+}
+
+// recordFetch completes a fetch record and appends it to the source history
+func recordFetch(key string, rec *FetchRecord, feed *Feed, err error) {
+	rec.DurationMS = time.Since(rec.Time).Milliseconds()
+	if feed != nil {
+		rec.Items = len(feed.Items)
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+
+	sourceHealthMutex.Lock()
+	defer sourceHealthMutex.Unlock()
+
+	h := getSourceHealth(key)
+	h.Fetches++
+	h.BytesTotal += rec.Bytes
+	h.LastStatus = rec.Status
+	h.LastAttempt = rec.Time
+	if err != nil {
+		h.Failures++
+	} else {
+		h.LastSuccess = rec.Time
+		h.LastItems = rec.Items
+	}
+	if rec.ParseError {
+		h.ParseErrors++
+	}
+
+	h.History = append(h.History, *rec)
+	if len(h.History) > fetchHistorySize {
+		h.History = h.History[len(h.History)-fetchHistorySize:]
+	}
+}
+
+// recordLiveItems notes how many items of a source fall in the 24 hour
+// real-time view and when the newest was published, which separates a quiet
+// feed from a dead one. Older items kept for history do not count as live.
+func recordLiveItems(key string, items []NewsItem) {
+	var newest time.Time
+	live := 0
+	for _, item := range items {
+		if item.PubDate.After(newest) {
+			newest = item.PubDate
+		}
+		if time.Since(item.PubDate) <= 24*time.Hour {
+			live++
+		}
+	}
+
+	sourceHealthMutex.Lock()
+	defer sourceHealthMutex.Unlock()
+
+	h := getSourceHealth(key)
+	h.LiveItems = live
+	if newest.After(h.NewestItem) {
+		h.NewestItem = newest
+	}
+}
+
+// collectSourceHealth snapshots the telemetry of every source, or of one
+// source when only is set, sorted by key
+func collectSourceHealth(only string) []SourceHealth {
+	sources := snapshotSources()
+
+	breakersMutex.Lock()
+	states := make(map[string]string, len(breakers))
+	for key, b := range breakers {
+		states[key] = b.State
+	}
+	breakersMutex.Unlock()
+
+	sourceHealthMutex.Lock()
+	report := make([]SourceHealth, 0, len(sources))
+	for key, src := range sources {
+		if only != "" && key != only {
+			continue
+		}
+
+		h := SourceHealth{Key: key}
+		if recorded, ok := sourceHealth[key]; ok {
+			h = *recorded
+			h.History = append([]FetchRecord(nil), recorded.History...)
+		}
+		h.Name = src.Name
+		h.Status = classifyHealth(h, states[key])
+
+		if len(h.History) > 0 {
+			var total int64
+			for _, rec := range h.History {
+				total += rec.DurationMS
+			}
+			h.AvgLatencyMS = float64(total) / float64(len(h.History))
+		}
+		report = append(report, h)
+	}
+	sourceHealthMutex.Unlock()
+
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Key < report[j].Key
+	})
+	return report
+}
+
+// sourcesHealthHandler reports fetch telemetry and rolling history for every
+// source, or for one source with ?source=KEY
+func sourcesHealthHandler(w http.ResponseWriter, r *http.Request) {
+	only := r.URL.Query().Get("source")
+	report := collectSourceHealth(only)
+
+	if only != "" && len(report) == 0 {
+		http.Error(w, "unknown source", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(report)
+}
+
+func classifyHealth(h SourceHealth, breakerState string) string {
+	switch {
+	case breakerState == breakerOpen || breakerState == breakerHalfOpen:
+		return healthDown
+	case h.Fetches == 0:
+		return healthUnknown
+	case h.History[len(h.History)-1].Error != "":
+		return healthFailing
+	case h.LiveItems == 0:
+		return healthQuiet
+	default:
+		return healthHealthy
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket upgrader
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		return true // Allow all origins for demo
+	},
+}
+
+// Largest message accepted from a client
+const maxClientMessageSize = 4096
+
+// Replies a client may have pending before its reader waits for the writer
+const clientReplyQueueSize = 8
+
+// webSocketTimeouts derives the heartbeat timings from WEBSOCKET_TIMEOUT:
+// a client that sends nothing, not even a pong, for the idle timeout is
+// disconnected, and pings go out often enough to keep a live one active
+func webSocketTimeouts() (idle, pingPeriod time.Duration) {
+	idle = time.Duration(appConfig.WebSocketTimeout)
+	return idle, idle * 9 / 10
+}
+
+// wsClient is the per-connection state. The reader goroutine updates the
+// subscription and queues replies; the writer goroutine is the only one
+// that writes to the connection.
+type wsClient struct {
+	conn    *websocket.Conn
+	sub     *Subscriber
+	replies chan []byte
+	done    chan struct{} // closed when the writer exits
+
+	mu           sync.Mutex
+	subscription Subscription
+	filter       *ItemFilter
+}
+
+func (c *wsClient) currentFilter() *ItemFilter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter
+}
+
+// handleWebSocket registers the connection with the broadcast hub, then
+// reads subscription messages until the client goes away. A client
+// reconnecting with ?since=<epoch>.<seq> is sent only the events it missed, or a
+// snapshot when they are no longer in the replay buffer. Like SSE, a token it
+// cannot resume from, including a malformed one, gets a snapshot.
+func handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	since, sinceErr := parseResumeToken(r.URL.Query().Get("since"))
+	resume := sinceErr == nil
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		return
+	}
+
+	var (
+		sub     *Subscriber
+		missed  []BroadcastEvent
+		resumed bool
+	)
+	if resume {
+		sub, _, missed, resumed = broadcastHub.Resume(kindWebSocket, since)
+	} else {
+		sub, _ = broadcastHub.Subscribe(kindWebSocket)
+	}
+	log.Printf("Client connected. Total clients: %d", broadcastHub.Count(kindWebSocket))
+
+	client := &wsClient{
+		conn:    conn,
+		sub:     sub,
+		replies: make(chan []byte, clientReplyQueueSize),
+		done:    make(chan struct{}),
+	}
+
+	// Missed events or initial real-time data go out ahead of queued updates
+	var initial [][]byte
+	if resumed {
+		log.Printf("🔁 WebSocket client resumed from %s with %d missed events", since, len(missed))
+		for _, event := range missed {
+			if data, err := client.encodeEvent(event); err == nil {
+				initial = append(initial, data)
+			}
+		}
+	} else if data, err := client.snapshotMessage(); err == nil {
+		initial = append(initial, data)
+	}
+	go client.writePump(initial)
+
+	// Pongs and client messages extend the read deadline; when it passes,
+	// ReadMessage fails and the client is unregistered
+	idle, _ := webSocketTimeouts()
+	conn.SetReadLimit(maxClientMessageSize)
+	conn.SetReadDeadline(time.Now().Add(idle))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(idle))
+	})
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				log.Printf("🔌 WebSocket client idle for %s; disconnecting", idle)
+			}
+			break
+		}
+		conn.SetReadDeadline(time.Now().Add(idle))
+		client.handleMessage(message)
+	}
+
+	broadcastHub.Unsubscribe(sub)
+	conn.Close()
+	log.Printf("Client disconnected. Total clients: %d", broadcastHub.Count(kindWebSocket))
+}
+
+// handleMessage applies a subscribe or unsubscribe message and queues the
+// acknowledgement followed by a snapshot matching the new subscription
+func (c *wsClient) handleMessage(message []byte) {
+	var msg ClientMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		c.queueReply(SubscriptionReply{Type: "error", Error: "invalid message: " + err.Error()})
+		return
+	}
+	if msg.Action == "resync" {
+		c.queueReply(SubscriptionReply{Type: "ack", Action: msg.Action, ID: msg.ID})
+		c.queueSnapshot()
+		return
+	}
+
+	c.mu.Lock()
+	next, err := c.subscription.Apply(msg)
+	var filter *ItemFilter
+	if err == nil {
+		filter, err = next.Filter()
+	}
+	if err == nil {
+		c.subscription, c.filter = next, filter
+	}
+	c.mu.Unlock()
+
+	if err != nil {
+		c.queueReply(SubscriptionReply{Type: "error", Action: msg.Action, ID: msg.ID, Error: err.Error()})
+		return
+	}
+	c.queueReply(SubscriptionReply{Type: "ack", Action: msg.Action, ID: msg.ID, Subscription: &next})
+	c.queueSnapshot()
+}
+
+func (c *wsClient) queueReply(reply SubscriptionReply) {
+	data, err := json.Marshal(reply)
+	if err != nil {
+		log.Printf("❌ Error encoding WebSocket reply: %v", err)
+		return
+	}
+	c.queue(data)
+}
+
+func (c *wsClient) queueSnapshot() {
+	if data, err := c.snapshotMessage(); err == nil {
+		c.queue(data)
+	}
+}
+
+// snapshotMessage encodes the full live view. The sequence number is read
+// before the data, so the snapshot reflects at least every event up to it.
+func (c *wsClient) snapshotMessage() ([]byte, error) {
+	seq := broadcastHub.LastID()
+	data, err := encodeForClient(currentNewsData(), c.currentFilter())
+	if err == nil {
+		data, err = encodeLiveMessage("snapshot", seq, data)
+	}
+	if err != nil {
+		log.Printf("❌ Error encoding snapshot: %v", err)
+	}
+	return data, err
+}
+
+// encodeEvent encodes a hub event for this client, applying its filter
+func (c *wsClient) encodeEvent(event BroadcastEvent) ([]byte, error) {
+	data := event.Data
+	var err error
+	if filter := c.currentFilter(); filter != nil {
+		data, err = encodeForClient(event.Payload, filter)
+	}
+	if err == nil {
+		data, err = encodeLiveMessage(event.Type, event.ID, data)
+	}
+	if err != nil {
+		log.Printf("❌ Error encoding %s event: %v", event.Type, err)
+	}
+	return data, err
+}
+
+// queue hands a message to the writer, waiting if its reply queue is full
+func (c *wsClient) queue(data []byte) {
+	select {
+	case c.replies <- data:
+	case <-c.done:
+	}
+}
+
+// writePump writes the initial messages, then drains replies and the
+// subscriber's queue onto the connection and sends periodic pings until the
+// queue is closed or a write fails, then closes the connection
+func (c *wsClient) writePump(initial [][]byte) {
+	idle, pingPeriod := webSocketTimeouts()
+	ping := time.NewTicker(pingPeriod)
+	defer func() {
+		ping.Stop()
+		close(c.done)
+		c.conn.Close()
+	}()
+
+	write := func(messageType int, data []byte) error {
+		c.conn.SetWriteDeadline(time.Now().Add(idle))
+		return c.conn.WriteMessage(messageType, data)
+	}
+
+	for _, data := range initial {
+		if err := write(websocket.TextMessage, data); err != nil {
+			broadcastHub.Unsubscribe(c.sub)
+			return
+		}
+	}
+
+	for {
+		var err error
+		select {
+		case data := <-c.replies:
+			err = write(websocket.TextMessage, data)
+
+		case event, ok := <-c.sub.Events:
+			if !ok {
+				// Unsubscribed or dropped by the hub
+				c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			data, encodeErr := c.encodeEvent(event)
+			if encodeErr != nil {
+				continue
+			}
+			err = write(websocket.TextMessage, data)
+
+		case <-ping.C:
+			err = write(websocket.PingMessage, nil)
+		}
+
+		if err != nil {
+			broadcastHub.Unsubscribe(c.sub)
+			return
+		}
+	}
+}
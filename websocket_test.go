@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialTestWebSocket starts a server for handleWebSocket and connects to it
+// with the given query string
+func dialTestWebSocket(t *testing.T, query string) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(handleWebSocket))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	return conn
+}
+
+func waitForWebSocketClients(t *testing.T, want int, within time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(within)
+	for broadcastHub.Count(kindWebSocket) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("websocket clients = %d, want %d", broadcastHub.Count(kindWebSocket), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// withWebSocketTimeout shortens the idle timeout for heartbeat tests
+func withWebSocketTimeout(t *testing.T, d time.Duration) {
+	saved := appConfig.WebSocketTimeout
+	appConfig.WebSocketTimeout = Duration(d)
+	t.Cleanup(func() { appConfig.WebSocketTimeout = saved })
+}
+
+// setLiveNews replaces currentNews and makes it the delta baseline
+func setLiveNews(t *testing.T, items []NewsItem) {
+	newsMutex.Lock()
+	saved := currentNews
+	currentNews = items
+	newsMutex.Unlock()
+	nextDelta(currentNewsData())
+
+	t.Cleanup(func() {
+		newsMutex.Lock()
+		currentNews = saved
+		newsMutex.Unlock()
+	})
+}
+
+// updateLiveNews replaces currentNews and broadcasts the change
+func updateLiveNews(items []NewsItem) {
+	newsMutex.Lock()
+	currentNews = items
+	newsMutex.Unlock()
+	broadcastUpdate()
+}
+
+func readLiveMessage(t *testing.T, conn *websocket.Conn, wantType string, payload interface{}) uint64 {
+	t.Helper()
+	var msg LiveMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("reading %s: %v", wantType, err)
+	}
+	if msg.Type != wantType {
+		t.Fatalf("message type = %q, want %q", msg.Type, wantType)
+	}
+	if err := json.Unmarshal(msg.Data, payload); err != nil {
+		t.Fatalf("decoding %s: %v", wantType, err)
+	}
+	return msg.Seq
+}
+
+func itemIDs(items []NewsItem) []string {
+	ids := []string{}
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestWebSocketSnapshotThenDeltas(t *testing.T) {
+	setLiveNews(t, []NewsItem{{ID: "1", Title: "One"}, {ID: "2", Title: "Two"}})
+	conn := dialTestWebSocket(t, "")
+
+	var snapshot NewsData
+	seq := readLiveMessage(t, conn, "snapshot", &snapshot)
+	if got := itemIDs(snapshot.Items); len(got) != 2 {
+		t.Fatalf("snapshot items = %v, want 2", got)
+	}
+
+	updateLiveNews([]NewsItem{{ID: "1", Title: "One, revised"}, {ID: "3", Title: "Three"}})
+	var delta NewsDelta
+	next := readLiveMessage(t, conn, "delta", &delta)
+	if next != seq+1 {
+		t.Fatalf("delta seq = %d, want %d", next, seq+1)
+	}
+	if got := itemIDs(delta.Added); len(got) != 1 || got[0] != "3" {
+		t.Fatalf("added = %v, want [3]", got)
+	}
+	if got := itemIDs(delta.Updated); len(got) != 1 || got[0] != "1" {
+		t.Fatalf("updated = %v, want [1]", got)
+	}
+	if len(delta.Removed) != 1 || delta.Removed[0] != "2" {
+		t.Fatalf("removed = %v, want [2]", delta.Removed)
+	}
+
+	// An unchanged view still produces a sequence number, with no changes
+	broadcastUpdate()
+	delta = NewsDelta{}
+	if got := readLiveMessage(t, conn, "delta", &delta); got != seq+2 {
+		t.Fatalf("delta seq = %d, want %d", got, seq+2)
+	}
+	if len(delta.Added)+len(delta.Updated)+len(delta.Removed) != 0 || delta.Analytics != nil {
+		t.Fatalf("unchanged view produced delta %+v", delta)
+	}
+
+	conn.Close()
+	waitForWebSocketClients(t, 0, 2*time.Second)
+}
+
+func TestWebSocketIdleClientIsDisconnected(t *testing.T) {
+	withWebSocketTimeout(t, time.Second)
+
+	// A client that never reads never answers pings
+	dialTestWebSocket(t, "")
+	waitForWebSocketClients(t, 1, time.Second)
+	waitForWebSocketClients(t, 0, 3*time.Second)
+}
+
+func TestWebSocketHeartbeatKeepsClientConnected(t *testing.T) {
+	withWebSocketTimeout(t, time.Second)
+
+	// Reading lets the default ping handler answer with pongs
+	conn := dialTestWebSocket(t, "")
+	conn.SetReadDeadline(time.Time{})
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	waitForWebSocketClients(t, 1, time.Second)
+	time.Sleep(2500 * time.Millisecond)
+	if got := broadcastHub.Count(kindWebSocket); got != 1 {
+		t.Fatalf("websocket clients = %d after heartbeats, want 1", got)
+	}
+}
+
+func TestWebSocketSubscriptionFiltersItems(t *testing.T) {
+	items := []NewsItem{
+		{ID: "1", Source: "ZP", Title: "TCS wins deal", Nifty50Stock: "TCS", SentimentLabel: "Positive"},
+		{ID: "2", Source: "NSE_IT", Title: "Infosys results", Nifty50Stock: "INFY", SentimentLabel: "Neutral"},
+	}
+	setLiveNews(t, items)
+	conn := dialTestWebSocket(t, "")
+
+	reply := func() SubscriptionReply {
+		t.Helper()
+		var r SubscriptionReply
+		if err := conn.ReadJSON(&r); err != nil {
+			t.Fatalf("reading reply: %v", err)
+		}
+		return r
+	}
+	snapshotIDs := func() []string {
+		t.Helper()
+		var data NewsData
+		readLiveMessage(t, conn, "snapshot", &data)
+		return itemIDs(data.Items)
+	}
+
+	if ids := snapshotIDs(); len(ids) != 2 {
+		t.Fatalf("initial snapshot items = %v, want both", ids)
+	}
+
+	conn.WriteJSON(ClientMessage{Action: "subscribe", ID: "a", Subscription: Subscription{Stocks: []string{"tcs"}}})
+	if r := reply(); r.Type != "ack" || r.ID != "a" || len(r.Subscription.Stocks) != 1 {
+		t.Fatalf("subscribe reply = %+v", r)
+	}
+	if ids := snapshotIDs(); len(ids) != 1 || ids[0] != "1" {
+		t.Fatalf("filtered snapshot items = %v, want [1]", ids)
+	}
+
+	// Both items change; only the subscribed one is delivered
+	updateLiveNews([]NewsItem{
+		{ID: "1", Source: "ZP", Title: "TCS wins bigger deal", Nifty50Stock: "TCS"},
+		{ID: "2", Source: "NSE_IT", Title: "Infosys results beat", Nifty50Stock: "INFY"},
+	})
+	var delta NewsDelta
+	readLiveMessage(t, conn, "delta", &delta)
+	if ids := itemIDs(delta.Updated); len(ids) != 1 || ids[0] != "1" {
+		t.Fatalf("filtered delta updates = %v, want [1]", ids)
+	}
+
+	conn.WriteJSON(ClientMessage{Action: "bogus"})
+	if r := reply(); r.Type != "error" {
+		t.Fatalf("unknown action reply = %+v, want error", r)
+	}
+
+	conn.WriteJSON(ClientMessage{Action: "unsubscribe", ID: "b"})
+	if r := reply(); r.Type != "ack" || r.Subscription == nil || !r.Subscription.Empty() {
+		t.Fatalf("unsubscribe reply = %+v", r)
+	}
+	if ids := snapshotIDs(); len(ids) != 2 {
+		t.Fatalf("snapshot after unsubscribe = %v, want both", ids)
+	}
+
+	conn.WriteJSON(ClientMessage{Action: "resync", ID: "c"})
+	if r := reply(); r.Type != "ack" || r.Action != "resync" {
+		t.Fatalf("resync reply = %+v", r)
+	}
+	if ids := snapshotIDs(); len(ids) != 2 {
+		t.Fatalf("resync snapshot = %v, want both", ids)
+	}
+}
+
+func TestWebSocketResumeSince(t *testing.T) {
+	setLiveNews(t, []NewsItem{{ID: "1", Title: "One"}})
+	first := dialTestWebSocket(t, "")
+
+	var snapshot NewsData
+	seq := readLiveMessage(t, first, "snapshot", &snapshot)
+	first.Close()
+
+	// Two updates happen while the client is away
+	updateLiveNews([]NewsItem{{ID: "1", Title: "One"}, {ID: "2", Title: "Two"}})
+	updateLiveNews([]NewsItem{{ID: "2", Title: "Two"}})
+
+	conn := dialTestWebSocket(t, "/?since="+broadcastHub.Token(seq).String())
+	var delta NewsDelta
+	if got := readLiveMessage(t, conn, "delta", &delta); got != seq+1 {
+		t.Fatalf("first replayed seq = %d, want %d", got, seq+1)
+	}
+	if ids := itemIDs(delta.Added); len(ids) != 1 || ids[0] != "2" {
+		t.Fatalf("replayed added = %v, want [2]", ids)
+	}
+	delta = NewsDelta{}
+	if got := readLiveMessage(t, conn, "delta", &delta); got != seq+2 {
+		t.Fatalf("second replayed seq = %d, want %d", got, seq+2)
+	}
+	if len(delta.Removed) != 1 || delta.Removed[0] != "1" {
+		t.Fatalf("replayed removed = %v, want [1]", delta.Removed)
+	}
+
+	// A sequence number the server has never issued gets a snapshot
+	stale := dialTestWebSocket(t, "/?since="+broadcastHub.Token(seq+1000).String())
+	snapshot = NewsData{}
+	if got := readLiveMessage(t, stale, "snapshot", &snapshot); got != seq+2 {
+		t.Fatalf("snapshot seq = %d, want %d", got, seq+2)
+	}
+	if ids := itemIDs(snapshot.Items); len(ids) != 1 || ids[0] != "2" {
+		t.Fatalf("snapshot items = %v, want [2]", ids)
+	}
+}
+
+func TestWebSocketResumeFromAnotherEpochGetsSnapshot(t *testing.T) {
+	setLiveNews(t, []NewsItem{{ID: "1", Title: "One"}})
+	first := dialTestWebSocket(t, "")
+	var snapshot NewsData
+	seq := readLiveMessage(t, first, "snapshot", &snapshot)
+	first.Close()
+
+	updateLiveNews([]NewsItem{{ID: "1", Title: "One"}, {ID: "2", Title: "Two"}})
+
+	// The seq is one this process has issued, but the token is from a
+	// previous process's stream, so replaying from it would be wrong
+	token := ResumeToken{Epoch: "previous", Seq: seq}
+	conn := dialTestWebSocket(t, "/?since="+token.String())
+
+	var msg LiveMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != "snapshot" || msg.Epoch != broadcastHub.Epoch() {
+		t.Fatalf("got %s with epoch %q, want a snapshot with epoch %q", msg.Type, msg.Epoch, broadcastHub.Epoch())
+	}
+	snapshot = NewsData{}
+	if err := json.Unmarshal(msg.Data, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if ids := itemIDs(snapshot.Items); len(ids) != 2 {
+		t.Fatalf("snapshot items = %v, want both", ids)
+	}
+}
+
+func TestWebSocketMalformedResumeTokenGetsSnapshot(t *testing.T) {
+	setLiveNews(t, []NewsItem{{ID: "1", Title: "One"}})
+
+	for _, since := range []string{"42", "", "not-a-token"} {
+		conn := dialTestWebSocket(t, "/?since="+since)
+		var snapshot NewsData
+		readLiveMessage(t, conn, "snapshot", &snapshot)
+		if ids := itemIDs(snapshot.Items); len(ids) != 1 {
+			t.Fatalf("since=%q: snapshot items = %v, want one", since, ids)
+		}
+		conn.Close()
+	}
+}